@@ -1,162 +1,250 @@
 package gooanda
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/kokweikhong/gooanda/endpoint"
 )
 
-// GetAccountList data structure
-type accountList struct { // {{{
-	Accounts []struct {
-		ID           string   `json:"id"`
-		Mt4AccountID int      `json:"mt4AccountID,omitempty"`
-		Tags         []string `json:"tags"`
-	}
-} // }}}
+// AccountList is the response of GetAccountList.
+type AccountList struct {
+	Accounts []AccountProperties `json:"accounts"`
+}
+
+// AccountProperties is the properties of an Account the token has access to.
+type AccountProperties struct {
+	ID           string   `json:"id"`
+	Mt4AccountID int      `json:"mt4AccountID,omitempty"`
+	Tags         []string `json:"tags"`
+}
+
+// AccountDetails is the response of GetAccountById.
+type AccountDetails struct {
+	Account           Account `json:"account"`
+	LastTransactionID string  `json:"lastTransactionID"`
+}
+
+// AccountSummaryDetails is the response of GetAccountSummary.
+type AccountSummaryDetails struct {
+	Account           AccountSummary `json:"account"`
+	LastTransactionID string         `json:"lastTransactionID"`
+}
 
-// GetAccountById data structure
-type accountById struct { // {{{
-	Account struct {
-		accountGlobal
-		Positions []struct {
-			Instrument   string                    `json:"instrument"`
-			Long         struct{ accountPosition } `json:"long"`
-			Short        struct{ accountPosition } `json:"short"`
-			UnrealizedPL float64                   `json:"unrealizedPL,string"`
-		} `json:"positions"`
-	} `json:"account"`
+// AccountInstruments is the response of GetAccountInstruments.
+type AccountInstruments struct {
+	Instruments       []Instrument `json:"instruments"`
+	LastTransactionID string       `json:"lastTransactionID"`
+}
+
+// Instrument is the details of an instrument tradeable by an Account.
+type Instrument struct { // {{{
+	DisplayName                 string  `json:"displayName"`
+	DisplayPrecision            int     `json:"displayPrecision"`
+	MarginRate                  Decimal `json:"marginRate"`
+	MaximumOrderUnits           Decimal `json:"maximumOrderUnits"`
+	MaximumPositionSize         Decimal `json:"maximumPositionSize"`
+	MaximumTrailingStopDistance Decimal `json:"maximumTrailingStopDistance"`
+	MinimumTradeSize            Decimal `json:"minimumTradeSize"`
+	MinimumTrailingStopDistance Decimal `json:"minimumTrailingStopDistance"`
+	Name                        string  `json:"name"`
+	PipLocation                 int     `json:"pipLocation"`
+	TradeUnitsPrecision         int     `json:"tradeUnitsPrecision"`
+	Type                        string  `json:"type"`
 } // }}}
 
-// GetAccountSummary data structure.
-type accountSummary struct { // {{{
-	Account struct {
-		accountGlobal
-		ResettablePL    float64 `json:"resettablePL,string"`
-		UnrealizedPL    float64 `json:"unrealizedPL,string"`
-		WithdrawalLimit float64 `json:"withdrawalLimit,string"`
-	} `json:"account"`
+// RoundPrice is price rounded to the DisplayPrecision of the instrument,
+// the precision of the prices accepted by the orders.
+func (i Instrument) RoundPrice(price Decimal) Decimal {
+	return price.Round(i.DisplayPrecision)
+}
+
+// RoundUnits is units truncated to the TradeUnitsPrecision of the instrument,
+// toward zero so that an order is never larger than the units requested.
+func (i Instrument) RoundUnits(units Decimal) Decimal {
+	return units.Truncate(i.TradeUnitsPrecision)
+}
+
+// AccountSummary is the state of an Account without its orders,
+// trades and positions.
+type AccountSummary struct { // {{{
+	ID                          string  `json:"id"`
+	Alias                       string  `json:"alias"`
+	Currency                    string  `json:"currency"`
+	CreatedByUserID             int     `json:"createdByUserID"`
+	CreatedTime                 Time    `json:"createdTime"`
+	GuaranteedStopLossOrderMode string  `json:"guaranteedStopLossOrderMode,omitempty"`
+	HedgingEnabled              bool    `json:"hedgingEnabled"`
+	Balance                     Decimal `json:"balance"`
+	PL                          Decimal `json:"pl"`
+	ResettablePL                Decimal `json:"resettablePL"`
+	ResettablePLTime            Time    `json:"resettablePLTime"`
+	Financing                   Decimal `json:"financing"`
+	Commission                  Decimal `json:"commission"`
+	DividendAdjustment          Decimal `json:"dividendAdjustment"`
+	GuaranteedExecutionFees     Decimal `json:"guaranteedExecutionFees"`
+	MarginRate                  Decimal `json:"marginRate"`
+	MarginCallEnterTime         Time    `json:"marginCallEnterTime"`
+	MarginCallExtensionCount    int     `json:"marginCallExtensionCount,omitempty"`
+	LastMarginCallExtensionTime Time    `json:"lastMarginCallExtensionTime"`
+	OpenTradeCount              int     `json:"openTradeCount"`
+	OpenPositionCount           int     `json:"openPositionCount"`
+	PendingOrderCount           int     `json:"pendingOrderCount"`
+	LastOrderFillTimestamp      Time    `json:"lastOrderFillTimestamp"`
+	UnrealizedPL                Decimal `json:"unrealizedPL"`
+	NAV                         Decimal `json:"NAV"`
+	MarginUsed                  Decimal `json:"marginUsed"`
+	MarginAvailable             Decimal `json:"marginAvailable"`
+	PositionValue               Decimal `json:"positionValue"`
+	MarginCloseoutUnrealizedPL  Decimal `json:"marginCloseoutUnrealizedPL"`
+	MarginCloseoutNAV           Decimal `json:"marginCloseoutNAV"`
+	MarginCloseoutMarginUsed    Decimal `json:"marginCloseoutMarginUsed"`
+	MarginCloseoutPercent       Decimal `json:"marginCloseoutPercent"`
+	MarginCloseoutPositionValue Decimal `json:"marginCloseoutPositionValue"`
+	WithdrawalLimit             Decimal `json:"withdrawalLimit"`
+	MarginCallMarginUsed        Decimal `json:"marginCallMarginUsed"`
+	MarginCallPercent           Decimal `json:"marginCallPercent"`
+	LastTransactionID           string  `json:"lastTransactionID"`
 } // }}}
 
-// GetAccountInstruments data structure.
-type accountInstruments struct { // {{{
-	Intruments []struct {
-		DisplayName                 string  `json:"displayName"`
-		DisplayPrecision            int     `json:"displayPrecision"`
-		MarginRate                  float64 `json:"marginRate,string"`
-		MaximumOrderUnits           float64 `json:"maximumOrderUnits,string"`
-		MaximumPositionSize         float64 `json:"maximumPositionSize,string"`
-		MaximumTrailingStopDistance float64 `json:"maximumTrailingStopDistance,string"`
-		MinimumTradeSize            float64 `json:"minimumTradeSize,string"`
-		MinimumTrailingStopDistance float64 `json:"minimumTrailingStopDistance,string"`
-		Name                        string  `json:"name"`
-		PipLocation                 int     `json:"pipLocation"`
-		TradeUnitsPrecision         int     `json:"tradeUnitsPrecision"`
-		Type                        string  `json:"type"`
-	} `json:"instruments"`
-	LastTransactionID string `json:"lastTransactionID"`
+// Account is the full details of an Account, with its pending orders,
+// open trades and positions.
+type Account struct {
+	AccountSummary
+	Orders    Orders         `json:"orders"`
+	Trades    []TradeSummary `json:"trades"`
+	Positions []Position     `json:"positions"`
+}
+
+// AccountConfiguration is the response of ConfigureAccount.
+type AccountConfiguration struct {
+	ClientConfigureTransaction *ClientConfigureTransaction `json:"clientConfigureTransaction"`
+	LastTransactionID          string                      `json:"lastTransactionID"`
+}
+
+// AccountChangesDetails is the response of GetAccountChanges, Changes is
+// the changes of the Account since the transaction requested up to
+// LastTransactionID and State its price-dependent state.
+type AccountChangesDetails struct {
+	Changes           AccountChanges      `json:"changes"`
+	State             AccountChangesState `json:"state"`
+	LastTransactionID string              `json:"lastTransactionID"`
+}
+
+// AccountChanges is the orders, trades and positions of an Account changed
+// by the transactions since a transaction.
+type AccountChanges struct { // {{{
+	OrdersCreated   Orders         `json:"ordersCreated"`
+	OrdersCancelled Orders         `json:"ordersCancelled"`
+	OrdersFilled    Orders         `json:"ordersFilled"`
+	OrdersTriggered Orders         `json:"ordersTriggered"`
+	TradesOpened    []TradeSummary `json:"tradesOpened"`
+	TradesReduced   []TradeSummary `json:"tradesReduced"`
+	TradesClosed    []TradeSummary `json:"tradesClosed"`
+	Positions       []Position     `json:"positions"`
+	Transactions    Transactions   `json:"transactions"`
 } // }}}
 
-type accountGlobal struct { // {{{
-	NAV                         string      `json:"NAV"`
-	Alias                       string      `json:"alias"`
-	Balance                     float64     `json:"balance,string"`
-	CreatedByUserID             int         `json:"createdByUserID"`
-	CreatedTime                 time.Time   `json:"createdTime"`
-	Currency                    string      `json:"currency"`
-	HedgingEnabled              bool        `json:"hedgingEnabled"`
-	Id                          string      `json:"id"`
-	LastTransactionID           string      `json:"lastTransactionID"`
-	MarginAvailable             float64     `json:"marginAvailable,string"`
-	MarginCloseoutMarginUsed    float64     `json:"marginCloseoutMarginUsed,string"`
-	MarginCloseoutNAV           float64     `json:"marginCloseoutNAV,string"`
-	MarginCloseoutPercent       float64     `json:"marginCloseoutPercent,string"`
-	MarginCloseoutPositionValue float64     `json:"marginCloseoutPositionValue,string"`
-	MarginCloseoutUnrealizedPL  float64     `json:"marginCloseoutUnrealizedPL,string"`
-	MarginRate                  float64     `json:"marginRate,string"`
-	MarginUsed                  float64     `json:"marginUsed,string"`
-	OpenPositionCount           int         `json:"openPositionCount"`
-	OpenTradeCount              int         `json:"openTradeCount"`
-	Orders                      interface{} `json:"orders"`
-	PendingOrderCount           int         `json:"pendingOrderCount"`
-	PL                          string      `json:"pl"`
-	PositionValue               string      `json:"positionValue"`
+// AccountChangesState is the state of an Account depending on the prices,
+// at the time of the lastTransactionID of the response.
+type AccountChangesState struct { // {{{
+	UnrealizedPL                Decimal                   `json:"unrealizedPL"`
+	NAV                         Decimal                   `json:"NAV"`
+	MarginUsed                  Decimal                   `json:"marginUsed"`
+	MarginAvailable             Decimal                   `json:"marginAvailable"`
+	PositionValue               Decimal                   `json:"positionValue"`
+	MarginCloseoutUnrealizedPL  Decimal                   `json:"marginCloseoutUnrealizedPL"`
+	MarginCloseoutNAV           Decimal                   `json:"marginCloseoutNAV"`
+	MarginCloseoutMarginUsed    Decimal                   `json:"marginCloseoutMarginUsed"`
+	MarginCloseoutPercent       Decimal                   `json:"marginCloseoutPercent"`
+	MarginCloseoutPositionValue Decimal                   `json:"marginCloseoutPositionValue"`
+	WithdrawalLimit             Decimal                   `json:"withdrawalLimit"`
+	MarginCallMarginUsed        Decimal                   `json:"marginCallMarginUsed"`
+	MarginCallPercent           Decimal                   `json:"marginCallPercent"`
+	Balance                     Decimal                   `json:"balance"`
+	PL                          Decimal                   `json:"pl"`
+	ResettablePL                Decimal                   `json:"resettablePL"`
+	Financing                   Decimal                   `json:"financing"`
+	Commission                  Decimal                   `json:"commission"`
+	Orders                      []DynamicOrderState       `json:"orders"`
+	Trades                      []CalculatedTradeState    `json:"trades"`
+	Positions                   []CalculatedPositionState `json:"positions"`
 } // }}}
 
-type accountPosition struct {
-	PL           float64 `json:"pl,string"`
-	ResettablePL float64 `json:"resettablePL,string"`
-	Units        float64 `json:"units,string"`
-	UnrealizedPL float64 `json:"unrealizedPL,string"`
+// DynamicOrderState is the state of a pending order depending on the prices.
+type DynamicOrderState struct {
+	ID                     string  `json:"id"`
+	TrailingStopValue      Decimal `json:"trailingStopValue"`
+	TriggerDistance        Decimal `json:"triggerDistance"`
+	IsTriggerDistanceExact bool    `json:"isTriggerDistanceExact,omitempty"`
 }
 
-type account struct {
-	connection
-	Query *accountFunc
+// CalculatedTradeState is the state of an open trade depending on the prices.
+type CalculatedTradeState struct {
+	ID           string  `json:"id"`
+	UnrealizedPL Decimal `json:"unrealizedPL"`
+	MarginUsed   Decimal `json:"marginUsed"`
 }
 
-// NewAccountConnection create a new connection for account endpoint
-func NewAccountConnection(token string) *account {
-	con := &account{}
-	con.connection.token = token
-	return con
+// CalculatedPositionState is the state of a position depending on the prices.
+type CalculatedPositionState struct {
+	Instrument        string  `json:"instrument"`
+	NetUnrealizedPL   Decimal `json:"netUnrealizedPL"`
+	LongUnrealizedPL  Decimal `json:"longUnrealizedPL"`
+	ShortUnrealizedPL Decimal `json:"shortUnrealizedPL"`
+	MarginUsed        Decimal `json:"marginUsed"`
 }
 
-func (ac *account) connect() ([]byte, error) {
-	con := &connection{ac.endpoint, ac.method, ac.token, ac.data}
-	resp, err := con.connect()
-	if err != nil {
-		return nil, err
-	}
-	return resp, nil
+// AccountService is the ACCOUNT API of Client.Accounts.
+type AccountService struct {
+	connection
+	Query *accountFunc
 }
 
 // GetAccountInstruments is to get the list of tradeable instruments for the given Account.
 // The list of tradeable instruments is dependent on the regulatory division
 // that the Account is located in, thus should be the same for all Accounts owned by a single user.
-func (ac *account) GetAccountInstruments(live bool, accountID string, querys ...accountOpts) (*accountInstruments, error) { // {{{
+func (ac *AccountService) GetAccountInstruments(querys ...AccountOpts) (*AccountInstruments, error) {
+	return ac.GetAccountInstrumentsContext(context.Background(), querys...)
+}
+
+// GetAccountInstrumentsContext is GetAccountInstruments with a context to cancel the request
+// or to set its deadline.
+func (ac *AccountService) GetAccountInstrumentsContext(ctx context.Context, querys ...AccountOpts) (*AccountInstruments, error) { // {{{
 	q := newAccountQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Account.AccountInstrument)
-	url := fmt.Sprintf(ep, accountID)
+	ep := ac.getEndpoint(endpoint.Account.AccountInstrument)
+	url := fmt.Sprintf(ep, ac.client.accountID)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	ac.endpoint = u
-	ac.method = http.MethodGet
-	resp, err := ac.connect()
-	if err != nil {
-		return nil, err
-	}
-	data := &accountInstruments{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	data := &AccountInstruments{}
+	if err = ac.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
 } // }}}
 
 // GetAccountSummary is to get a summary for a single Account that a client has access to.
-func (ac *account) GetAccountSummary(live bool, accountID string, querys ...accountOpts) (*accountSummary, error) { // {{{
+func (ac *AccountService) GetAccountSummary(querys ...AccountOpts) (*AccountSummaryDetails, error) {
+	return ac.GetAccountSummaryContext(context.Background(), querys...)
+}
+
+// GetAccountSummaryContext is GetAccountSummary with a context to cancel the request
+// or to set its deadline.
+func (ac *AccountService) GetAccountSummaryContext(ctx context.Context, querys ...AccountOpts) (*AccountSummaryDetails, error) { // {{{
 	q := newAccountQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Account.AccountSummary)
-	url := fmt.Sprintf(ep, accountID)
+	ep := ac.getEndpoint(endpoint.Account.AccountSummary)
+	url := fmt.Sprintf(ep, ac.client.accountID)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	ac.endpoint = u
-	ac.method = http.MethodGet
-	resp, err := ac.connect()
-	if err != nil {
-		return nil, err
-	}
-	data := &accountSummary{}
-	err = json.Unmarshal(resp, &data)
-	if err != nil {
+	data := &AccountSummaryDetails{}
+	if err = ac.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
@@ -164,22 +252,22 @@ func (ac *account) GetAccountSummary(live bool, accountID string, querys ...acco
 
 // GetAccountById is to get the full details for a single Account that a client has access to.
 // Full pending Order, open Trade and open Position representations are provided.
-func (ac *account) GetAccountById(live bool, accountID string, querys ...accountOpts) (*accountById, error) { // {{{
+func (ac *AccountService) GetAccountById(querys ...AccountOpts) (*AccountDetails, error) {
+	return ac.GetAccountByIdContext(context.Background(), querys...)
+}
+
+// GetAccountByIdContext is GetAccountById with a context to cancel the request
+// or to set its deadline.
+func (ac *AccountService) GetAccountByIdContext(ctx context.Context, querys ...AccountOpts) (*AccountDetails, error) { // {{{
 	q := newAccountQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Account.AccountsById)
-	url := fmt.Sprintf(ep, accountID)
+	ep := ac.getEndpoint(endpoint.Account.AccountsById)
+	url := fmt.Sprintf(ep, ac.client.accountID)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	ac.endpoint = u
-	ac.method = http.MethodGet
-	resp, err := ac.connect()
-	if err != nil {
-		return nil, err
-	}
-	data := &accountById{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	data := &AccountDetails{}
+	if err = ac.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
@@ -187,22 +275,22 @@ func (ac *account) GetAccountById(live bool, accountID string, querys ...account
 
 // GetAccountList is to get the full details for a single Account that a client has access to.
 // Full pending Order, open Trade and open Position representations are provided.
-func (ac *account) GetAccountList(live bool, querys ...accountOpts) (*accountList, error) { // {{{
+func (ac *AccountService) GetAccountList(querys ...AccountOpts) (*AccountList, error) {
+	return ac.GetAccountListContext(context.Background(), querys...)
+}
+
+// GetAccountListContext is GetAccountList with a context to cancel the request
+// or to set its deadline.
+func (ac *AccountService) GetAccountListContext(ctx context.Context, querys ...AccountOpts) (*AccountList, error) { // {{{
 	q := newAccountQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Account.Accounts)
+	ep := ac.getEndpoint(endpoint.Account.Accounts)
 	url := ep
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	ac.endpoint = u
-	ac.method = http.MethodGet
-	resp, err := ac.connect()
-	if err != nil {
-		return nil, err
-	}
-	var data = &accountList{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	var data = &AccountList{}
+	if err = ac.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
@@ -213,9 +301,10 @@ type accountQuery struct { // {{{
 	Instruments        string `json:"instruments,omitempty"`
 } // }}}
 
-type accountOpts func(*accountQuery)
+// AccountOpts is an option of the account requests.
+type AccountOpts func(*accountQuery)
 
-func newAccountQuery(querys ...accountOpts) *accountQuery {
+func newAccountQuery(querys ...AccountOpts) *accountQuery {
 	q := &accountQuery{}
 	for _, query := range querys {
 		query(q)
@@ -226,15 +315,67 @@ func newAccountQuery(querys ...accountOpts) *accountQuery {
 type accountFunc struct{}
 
 // WithInstruments is list of instruments to query specifically.
-func (*accountFunc) WithInstruments(instruments []string) accountOpts { // {{{
+func (*accountFunc) WithInstruments(instruments []string) AccountOpts { // {{{
 	return func(aq *accountQuery) {
 		aq.Instruments = strings.Join(instruments, ",")
 	}
 } // }}}
 
 // WithSinceTransactionID is ID of the Transaction to get Account changes since.
-func (*accountFunc) WithSinceTransactionID(transactionID string) accountOpts { // {{{
+func (*accountFunc) WithSinceTransactionID(transactionID string) AccountOpts { // {{{
 	return func(aq *accountQuery) {
 		aq.SinceTransactionID = transactionID
 	}
 } // }}}
+
+// ConfigureAccount is to set the client-configurable portions of an Account,
+// an empty alias or an unset marginRate is left unchanged.
+func (ac *AccountService) ConfigureAccount(alias string, marginRate Decimal) (*AccountConfiguration, error) {
+	return ac.ConfigureAccountContext(context.Background(), alias, marginRate)
+}
+
+// ConfigureAccountContext is ConfigureAccount with a context to cancel the request
+// or to set its deadline.
+func (ac *AccountService) ConfigureAccountContext(ctx context.Context, alias string, marginRate Decimal) (*AccountConfiguration, error) { // {{{
+	config := struct {
+		Alias      string   `json:"alias,omitempty"`
+		MarginRate *Decimal `json:"marginRate,omitempty"`
+	}{Alias: alias}
+	if marginRate.IsSet() {
+		config.MarginRate = &marginRate
+	}
+	body, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal account configuration, %v", err)
+	}
+	ep := ac.getEndpoint(endpoint.Account.AccountConfiguration)
+	url := fmt.Sprintf(ep, ac.client.accountID)
+	data := &AccountConfiguration{}
+	if err = ac.connectJSON(ctx, &request{method: http.MethodPatch, endpoint: url, data: body}, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+} // }}}
+
+// GetAccountChanges is to poll an Account for its current state and changes
+// since a specified TransactionID.
+func (ac *AccountService) GetAccountChanges(sinceTransactionID string) (*AccountChangesDetails, error) {
+	return ac.GetAccountChangesContext(context.Background(), sinceTransactionID)
+}
+
+// GetAccountChangesContext is GetAccountChanges with a context to cancel the request
+// or to set its deadline.
+func (ac *AccountService) GetAccountChangesContext(ctx context.Context, sinceTransactionID string) (*AccountChangesDetails, error) { // {{{
+	q := newAccountQuery(ac.Query.WithSinceTransactionID(sinceTransactionID))
+	ep := ac.getEndpoint(endpoint.Account.AccountChanges)
+	url := fmt.Sprintf(ep, ac.client.accountID)
+	u, err := urlAddQuery(url, q)
+	if err != nil {
+		return nil, err
+	}
+	data := &AccountChangesDetails{}
+	if err = ac.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+} // }}}
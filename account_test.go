@@ -0,0 +1,122 @@
+package gooanda_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+func TestGetAccountList(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts" {
+			t.Errorf("path = %v, want /v3/accounts", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("authorization = %v", got)
+		}
+		fmt.Fprint(w, `{"accounts":[{"id":"101-001-1-001","tags":[]}]}`)
+	})
+	data, err := client.Accounts().GetAccountList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Accounts) != 1 || data.Accounts[0].ID != "101-001-1-001" {
+		t.Errorf("accounts = %+v", data.Accounts)
+	}
+}
+
+func TestGetAccountById(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"account":{"id":"101-001-1-001","alias":"Primary","currency":"USD","createdByUserID":1,"createdTime":"2021-01-01T00:00:00.000000000Z",`+
+			`"guaranteedStopLossOrderMode":"DISABLED","hedgingEnabled":false,"balance":"99999.8000","pl":"-0.2000","resettablePL":"-0.2000","resettablePLTime":"0",`+
+			`"financing":"0.0000","commission":"0.0000","dividendAdjustment":"0","guaranteedExecutionFees":"0.0000","marginRate":"0.02",`+
+			`"openTradeCount":1,"openPositionCount":1,"pendingOrderCount":1,"lastOrderFillTimestamp":"2021-01-01T00:00:01.000000000Z",`+
+			`"unrealizedPL":"1.5000","NAV":"100001.3000","marginUsed":"4.8800","marginAvailable":"99996.4200","positionValue":"122.0100",`+
+			`"marginCloseoutUnrealizedPL":"1.5000","marginCloseoutNAV":"100001.3000","marginCloseoutMarginUsed":"4.8800","marginCloseoutPercent":"0.00002",`+
+			`"marginCloseoutPositionValue":"122.0100","withdrawalLimit":"99996.4200","marginCallMarginUsed":"4.8800","marginCallPercent":"0.00005","lastTransactionID":"8",`+
+			`"orders":[{"id":"8","type":"STOP_LOSS","state":"PENDING","tradeID":"7","price":"1.21000","timeInForce":"GTC"}],`+
+			`"trades":[{"id":"7","instrument":"EUR_USD","price":"1.22010","openTime":"2021-01-01T00:00:01.000000000Z","state":"OPEN","initialUnits":"100","currentUnits":"100",`+
+			`"realizedPL":"0.0000","unrealizedPL":"1.5000","marginUsed":"4.8800","financing":"0.0000","stopLossOrderID":"8"}],`+
+			`"positions":[%v]},"lastTransactionID":"8"}`, testPosition)
+	})
+	data, err := client.Accounts().GetAccountById()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := data.Account
+	if account.ID != "101-001-1-001" || !account.NAV.Equal(dec("100001.3")) || !account.PL.Equal(dec("-0.2")) || !account.PositionValue.Equal(dec("122.01")) || !account.MarginCallPercent.Equal(dec("0.00005")) {
+		t.Errorf("summary = %+v", account.AccountSummary)
+	}
+	if account.GuaranteedStopLossOrderMode != "DISABLED" || account.LastOrderFillTimestamp.Second() != 1 || account.ResettablePLTime.Unix() != 0 {
+		t.Errorf("summary = %+v", account.AccountSummary)
+	}
+	if sl, ok := account.Orders[0].(*gooanda.StopLossOrder); !ok || sl.TradeID != "7" || !sl.Price.Equal(dec("1.21")) {
+		t.Errorf("orders = %#v", account.Orders)
+	}
+	if trade := account.Trades[0]; trade.StopLossOrderID != "8" || !trade.UnrealizedPL.Equal(dec("1.5")) {
+		t.Errorf("trades = %+v", account.Trades)
+	}
+	if long := account.Positions[0].Long; !long.AveragePrice.Equal(dec("1.22")) || long.TradeIDs[0] != "7" || !long.Units.Equal(dec("100")) {
+		t.Errorf("positions = %+v", account.Positions)
+	}
+}
+
+func TestConfigureAccount(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/v3/accounts/101-001-1-001/configuration" {
+			t.Errorf("request = %v %v", r.Method, r.URL.Path)
+		}
+		if body, _ := ioutil.ReadAll(r.Body); string(body) != `{"alias":"Primary","marginRate":"0.05"}` {
+			t.Errorf("body = %s", body)
+		}
+		fmt.Fprint(w, `{"clientConfigureTransaction":{"id":"18","type":"CLIENT_CONFIGURE","alias":"Primary","marginRate":"0.05"},"lastTransactionID":"18"}`)
+	})
+	data, err := client.Accounts().ConfigureAccount("Primary", dec("0.05"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configure := data.ClientConfigureTransaction; configure.Alias != "Primary" || !configure.MarginRate.Equal(dec("0.05")) {
+		t.Errorf("clientConfigureTransaction = %+v", configure)
+	}
+}
+
+func TestGetAccountChanges(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/changes" || r.URL.Query().Get("sinceTransactionID") != "6" {
+			t.Errorf("url = %v", r.URL)
+		}
+		fmt.Fprintf(w, `{"changes":{"ordersCreated":[],"ordersCancelled":[],"ordersFilled":[{"id":"6","type":"MARKET","state":"FILLED","instrument":"EUR_USD","units":"100","fillingTransactionID":"7","tradeOpenedID":"7"}],"ordersTriggered":[],`+
+			`"tradesOpened":[{"id":"7","instrument":"EUR_USD","price":"1.22010","openTime":"2021-01-01T00:00:01.000000000Z","state":"OPEN","initialUnits":"100","currentUnits":"100","realizedPL":"0.0000","financing":"0.0000","stopLossOrderID":"8"}],`+
+			`"tradesReduced":[],"tradesClosed":[],"positions":[%v],"transactions":[%v]},`+
+			`"state":{"unrealizedPL":"1.5000","NAV":"100001.5000","marginUsed":"4.8800","marginAvailable":"99996.6200","positionValue":"122.0100","marginCloseoutUnrealizedPL":"1.5000","marginCloseoutNAV":"100001.5000",`+
+			`"marginCloseoutMarginUsed":"4.8800","marginCloseoutPercent":"0.00002","marginCloseoutPositionValue":"122.0100","withdrawalLimit":"99996.6200","orders":[],`+
+			`"trades":[{"id":"7","unrealizedPL":"1.5000","marginUsed":"4.8800"}],"positions":[{"instrument":"EUR_USD","netUnrealizedPL":"1.5000","longUnrealizedPL":"1.5000","shortUnrealizedPL":"0.0000","marginUsed":"4.8800"}]},`+
+			`"lastTransactionID":"7"}`, testPosition, testOrderFill)
+	})
+	data, err := client.Accounts().GetAccountChanges("6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := data.Changes
+	if filled, ok := changes.OrdersFilled[0].(*gooanda.MarketOrder); !ok || filled.TradeOpenedID != "7" {
+		t.Errorf("ordersFilled = %#v", changes.OrdersFilled)
+	}
+	if len(changes.TradesOpened) != 1 || changes.TradesOpened[0].StopLossOrderID != "8" {
+		t.Errorf("tradesOpened = %+v", changes.TradesOpened)
+	}
+	if _, ok := changes.Transactions[0].(*gooanda.OrderFillTransaction); !ok || len(changes.Positions) != 1 {
+		t.Errorf("transactions = %#v, positions = %+v", changes.Transactions, changes.Positions)
+	}
+	if state := data.State; !state.NAV.Equal(dec("100001.5")) || !state.Trades[0].UnrealizedPL.Equal(dec("1.5")) || !state.Positions[0].MarginUsed.Equal(dec("4.88")) {
+		t.Errorf("state = %+v", state)
+	}
+	if data.LastTransactionID != "7" {
+		t.Errorf("lastTransactionID = %v", data.LastTransactionID)
+	}
+}
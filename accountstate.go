@@ -0,0 +1,348 @@
+package gooanda
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultAccountPollInterval is the time between two polls of the changes
+// of an Account by AccountState.
+var DefaultAccountPollInterval = time.Second
+
+// AccountState is a mirror of an Account, loaded once with GetAccountById
+// and then kept up to date by polling GetAccountChanges from the last
+// transaction applied. It is safe for concurrent use.
+type AccountState struct {
+	accounts *AccountService
+	interval time.Duration
+
+	// pollMu serializes the polls, mu guards the account and the callbacks.
+	pollMu   sync.Mutex
+	mu       sync.RWMutex
+	account  Account
+	loaded   bool
+	onChange []func(AccountStateChange)
+	onError  []func(error)
+}
+
+// AccountStateChange is sent to the change callbacks after a poll changed
+// the Account, Account is the snapshot with the changes applied.
+type AccountStateChange struct {
+	Changes AccountChanges
+	State   AccountChangesState
+	Account Account
+}
+
+// AccountStateOpts is an option of AccountState.
+type AccountStateOpts func(*AccountState)
+
+// WithPollInterval is the time between two polls of the account changes.
+// [default=DefaultAccountPollInterval]
+func WithPollInterval(interval time.Duration) AccountStateOpts {
+	return func(s *AccountState) { s.interval = interval }
+}
+
+// NewAccountState is to create the state of the default Account of client,
+// use Run to load and to keep it up to date.
+func NewAccountState(client *Client, opts ...AccountStateOpts) *AccountState {
+	s := &AccountState{
+		accounts: client.Accounts(),
+		interval: DefaultAccountPollInterval,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// OnChange is to add a callback called after every poll which changed the
+// Account, the callbacks are called one at a time from the polling goroutine.
+func (s *AccountState) OnChange(fn func(AccountStateChange)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+// OnError is to add a callback called with the errors of the polls run by
+// Run, the Account is polled again at the next interval.
+func (s *AccountState) OnError(fn func(error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = append(s.onError, fn)
+}
+
+// Snapshot is a copy of the current state of the Account, it is the zero
+// Account until the Account is loaded. The orders are shared between the
+// snapshots and must not be modified.
+func (s *AccountState) Snapshot() Account {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.account.clone()
+}
+
+// Load is to load the full Account, replacing the current state.
+func (s *AccountState) Load(ctx context.Context) error {
+	s.pollMu.Lock()
+	defer s.pollMu.Unlock()
+	return s.load(ctx)
+}
+
+func (s *AccountState) load(ctx context.Context) error {
+	details, err := s.accounts.GetAccountByIdContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load account state, %w", err)
+	}
+	details.Account.LastTransactionID = details.LastTransactionID
+	s.mu.Lock()
+	s.account = details.Account
+	s.loaded = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Poll is to apply the changes of the Account since the last transaction
+// applied, the Account is loaded first if it is not yet. The change callbacks
+// are called once the poll is done, they may call Poll or Load.
+func (s *AccountState) Poll(ctx context.Context) error {
+	s.pollMu.Lock()
+	change, callbacks, err := s.poll(ctx)
+	s.pollMu.Unlock()
+	if err != nil || change == nil {
+		return err
+	}
+	for _, fn := range callbacks {
+		fn(*change)
+	}
+	return nil
+}
+
+// poll is to apply the changes of the Account, the change is nil when the
+// poll did not change the Account. pollMu must be held.
+func (s *AccountState) poll(ctx context.Context) (*AccountStateChange, []func(AccountStateChange), error) { // {{{
+	s.mu.RLock()
+	loaded, since := s.loaded, s.account.LastTransactionID
+	s.mu.RUnlock()
+	if !loaded {
+		return nil, nil, s.load(ctx)
+	}
+	details, err := s.accounts.GetAccountChangesContext(ctx, since)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to poll account changes since %v, %w", since, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.account.AccountSummary
+	s.account.applyChanges(details)
+	if len(details.Changes.Transactions) == 0 && s.account.AccountSummary == previous {
+		return nil, nil, nil
+	}
+	change := &AccountStateChange{Changes: details.Changes, State: details.State, Account: s.account.clone()}
+	return change, s.onChange, nil
+} // }}}
+
+// Run is to load the Account and to poll its changes at every interval until
+// ctx is done. An error loading the Account is returned, the errors of the
+// polls are sent to the error callbacks. The error of ctx is returned when
+// it is done.
+func (s *AccountState) Run(ctx context.Context) error { // {{{
+	if err := s.Load(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := s.Poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.mu.RLock()
+			callbacks := s.onError
+			s.mu.RUnlock()
+			for _, fn := range callbacks {
+				fn(err)
+			}
+		}
+	}
+} // }}}
+
+// clone is a copy of a which does not share its slices.
+func (a Account) clone() Account {
+	a.Orders = append(Orders(nil), a.Orders...)
+	a.Trades = append([]TradeSummary(nil), a.Trades...)
+	positions := make([]Position, len(a.Positions))
+	for i, p := range a.Positions {
+		p.Long.TradeIDs = append([]string(nil), p.Long.TradeIDs...)
+		p.Short.TradeIDs = append([]string(nil), p.Short.TradeIDs...)
+		positions[i] = p
+	}
+	a.Positions = positions
+	return a
+}
+
+// applyChanges is to apply the changes and the price-dependent state of
+// a poll to a. The orders are replaced instead of being modified as they
+// are shared with the snapshots.
+func (a *Account) applyChanges(details *AccountChangesDetails) { // {{{
+	changes, state := details.Changes, details.State
+
+	// an order created and filled since the last poll is in both lists.
+	orders := append(append(Orders(nil), a.Orders...), changes.OrdersCreated...)
+	done := map[string]bool{}
+	for _, list := range []Orders{changes.OrdersFilled, changes.OrdersCancelled, changes.OrdersTriggered} {
+		for _, o := range list {
+			done[o.Base().ID] = true
+		}
+	}
+	a.Orders = a.Orders[:0:0]
+	for _, o := range orders {
+		if !done[o.Base().ID] {
+			a.Orders = append(a.Orders, o)
+		}
+	}
+
+	trades := append(append([]TradeSummary(nil), a.Trades...), changes.TradesOpened...)
+	reduced := map[string]TradeSummary{}
+	for _, t := range changes.TradesReduced {
+		reduced[t.ID] = t
+	}
+	closed := map[string]bool{}
+	for _, t := range changes.TradesClosed {
+		closed[t.ID] = true
+	}
+	a.Trades = a.Trades[:0:0]
+	for _, t := range trades {
+		if closed[t.ID] {
+			continue
+		}
+		if r, ok := reduced[t.ID]; ok {
+			t = r
+		}
+		a.Trades = append(a.Trades, t)
+	}
+
+	for _, p := range changes.Positions {
+		a.setPosition(p)
+	}
+
+	for _, t := range changes.Transactions {
+		switch t := t.(type) {
+		case *OrderFillTransaction:
+			a.Balance = t.AccountBalance
+			a.PL = a.PL.Add(t.PL)
+			a.ResettablePL = a.ResettablePL.Add(t.PL)
+			a.Financing = a.Financing.Add(t.Financing)
+			a.Commission = a.Commission.Add(t.Commission)
+			a.GuaranteedExecutionFees = a.GuaranteedExecutionFees.Add(t.GuaranteedExecutionFee)
+			a.LastOrderFillTimestamp = t.Time
+		case *TransferFundsTransaction:
+			a.Balance = t.AccountBalance
+		case *DailyFinancingTransaction:
+			a.Balance = t.AccountBalance
+			a.Financing = a.Financing.Add(t.Financing)
+		case *DividendAdjustmentTransaction:
+			a.Balance = t.AccountBalance
+			a.DividendAdjustment = a.DividendAdjustment.Add(t.DividendAdjustment)
+		case *ResetResettablePLTransaction:
+			a.ResettablePL = DecimalFromInt(0)
+			a.ResettablePLTime = t.Time
+		case *ClientConfigureTransaction:
+			if t.Alias != "" {
+				a.Alias = t.Alias
+			}
+			if t.MarginRate.IsSet() {
+				a.MarginRate = t.MarginRate
+			}
+		}
+	}
+
+	a.applyState(state)
+	a.PendingOrderCount = len(a.Orders)
+	a.OpenTradeCount = len(a.Trades)
+	a.OpenPositionCount = 0
+	for _, p := range a.Positions {
+		if !p.Long.Units.IsZero() || !p.Short.Units.IsZero() {
+			a.OpenPositionCount++
+		}
+	}
+	a.LastTransactionID = details.LastTransactionID
+} // }}}
+
+// applyState is to apply the price-dependent state of a poll to a.
+func (a *Account) applyState(state AccountChangesState) { // {{{
+	a.UnrealizedPL = state.UnrealizedPL
+	a.NAV = state.NAV
+	a.MarginUsed = state.MarginUsed
+	a.MarginAvailable = state.MarginAvailable
+	a.PositionValue = state.PositionValue
+	a.MarginCloseoutUnrealizedPL = state.MarginCloseoutUnrealizedPL
+	a.MarginCloseoutNAV = state.MarginCloseoutNAV
+	a.MarginCloseoutMarginUsed = state.MarginCloseoutMarginUsed
+	a.MarginCloseoutPercent = state.MarginCloseoutPercent
+	a.MarginCloseoutPositionValue = state.MarginCloseoutPositionValue
+	a.WithdrawalLimit = state.WithdrawalLimit
+	a.MarginCallMarginUsed = state.MarginCallMarginUsed
+	a.MarginCallPercent = state.MarginCallPercent
+	// the balance and the profits are only in the state of the recent
+	// versions of the api, the transactions replayed are kept otherwise.
+	if state.Balance.IsSet() {
+		a.Balance = state.Balance
+	}
+	if state.PL.IsSet() {
+		a.PL = state.PL
+	}
+	if state.ResettablePL.IsSet() {
+		a.ResettablePL = state.ResettablePL
+	}
+	if state.Financing.IsSet() {
+		a.Financing = state.Financing
+	}
+	if state.Commission.IsSet() {
+		a.Commission = state.Commission
+	}
+
+	for _, s := range state.Trades {
+		for i := range a.Trades {
+			if a.Trades[i].ID == s.ID {
+				a.Trades[i].UnrealizedPL = s.UnrealizedPL
+				a.Trades[i].MarginUsed = s.MarginUsed
+			}
+		}
+	}
+	for _, s := range state.Positions {
+		for i := range a.Positions {
+			if p := &a.Positions[i]; p.Instrument == s.Instrument {
+				p.UnrealizedPL = s.NetUnrealizedPL
+				p.Long.UnrealizedPL = s.LongUnrealizedPL
+				p.Short.UnrealizedPL = s.ShortUnrealizedPL
+				p.MarginUsed = s.MarginUsed
+			}
+		}
+	}
+	for _, s := range state.Orders {
+		for i, o := range a.Orders {
+			if o, ok := o.(*TrailingStopLossOrder); ok && o.ID == s.ID {
+				updated := *o
+				updated.TrailingStopValue = s.TrailingStopValue
+				a.Orders[i] = &updated
+			}
+		}
+	}
+} // }}}
+
+// setPosition is to replace the position of the instrument of p by p.
+func (a *Account) setPosition(p Position) {
+	for i := range a.Positions {
+		if a.Positions[i].Instrument == p.Instrument {
+			a.Positions[i] = p
+			return
+		}
+	}
+	a.Positions = append(a.Positions, p)
+}
@@ -0,0 +1,238 @@
+package gooanda_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+const testAccount = `{"account":{"id":"101-001-1-001","currency":"USD","balance":"100000.0000","NAV":"100000.0000","marginUsed":"0.0000","lastTransactionID":"6",` +
+	`"pendingOrderCount":1,"openTradeCount":1,"openPositionCount":1,` +
+	`"orders":[{"id":"5","type":"LIMIT","state":"PENDING","instrument":"EUR_USD","units":"100","price":"1.22010"}],` +
+	`"trades":[{"id":"3","instrument":"USD_JPY","price":"103.000","state":"OPEN","initialUnits":"10","currentUnits":"10"}],` +
+	`"positions":[{"instrument":"USD_JPY","long":{"units":"10","tradeIDs":["3"]},"short":{"units":"0"}}]},"lastTransactionID":"6"}`
+
+// testAccountChanges is the changes served by the fake server since every
+// transaction ID.
+var testAccountChanges = map[string]string{
+	// the limit order 5 is filled, opening the trade 7.
+	"6": `{"changes":{"ordersFilled":[{"id":"5","type":"LIMIT","state":"FILLED","instrument":"EUR_USD","units":"100","price":"1.22010"}],` +
+		`"tradesOpened":[{"id":"7","instrument":"EUR_USD","price":"1.22010","state":"OPEN","initialUnits":"100","currentUnits":"100"}],` +
+		`"positions":[` + testPosition + `],` +
+		`"transactions":[{"id":"7","type":"ORDER_FILL","orderID":"5","instrument":"EUR_USD","units":"100","accountBalance":"99999.8000"}]},` +
+		`"state":{"NAV":"100001.3000","unrealizedPL":"1.5000","marginUsed":"4.8800","positionValue":"122.0100",` +
+		`"trades":[{"id":"7","unrealizedPL":"1.5000","marginUsed":"4.8800"}],` +
+		`"positions":[{"instrument":"EUR_USD","netUnrealizedPL":"1.5000","longUnrealizedPL":"1.5000","marginUsed":"4.8800"}]},"lastTransactionID":"7"}`,
+	// the trade 3 is closed and a trailing stop loss is created on the trade 7.
+	"7": `{"changes":{"ordersCreated":[{"id":"9","type":"TRAILING_STOP_LOSS","state":"PENDING","tradeID":"7","distance":"0.00500"}],` +
+		`"tradesClosed":[{"id":"3","instrument":"USD_JPY","state":"CLOSED","initialUnits":"10","currentUnits":"0"}],` +
+		`"positions":[{"instrument":"USD_JPY","long":{"units":"0"},"short":{"units":"0"}}],` +
+		`"transactions":[{"id":"8","type":"ORDER_FILL","instrument":"USD_JPY","units":"-10","pl":"0.3000","accountBalance":"100000.1000"},{"id":"9","type":"TRAILING_STOP_LOSS_ORDER","tradeID":"7"}]},` +
+		`"state":{"NAV":"100001.6000","unrealizedPL":"1.5000","marginUsed":"4.8800","positionValue":"122.0100",` +
+		`"orders":[{"id":"9","trailingStopValue":"1.21720"}]},"lastTransactionID":"9"}`,
+	// only the prices moved.
+	"9": `{"changes":{},"state":{"NAV":"100002.1000","unrealizedPL":"2.0000","marginUsed":"4.8800","positionValue":"122.0100",` +
+		`"orders":[{"id":"9","trailingStopValue":"1.21770"}],"trades":[{"id":"7","unrealizedPL":"2.0000","marginUsed":"4.8800"}]},"lastTransactionID":"9"}`,
+}
+
+func newTestAccountStateClient(t *testing.T) *gooanda.Client {
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/accounts/101-001-1-001":
+			fmt.Fprint(w, testAccount)
+		case "/v3/accounts/101-001-1-001/changes":
+			changes, ok := testAccountChanges[r.URL.Query().Get("sinceTransactionID")]
+			if !ok {
+				t.Errorf("url = %v", r.URL)
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, changes)
+		default:
+			t.Errorf("path = %v", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func orderIDs(orders gooanda.Orders) string {
+	var ids []string
+	for _, o := range orders {
+		ids = append(ids, o.Base().ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestAccountStatePoll(t *testing.T) {
+	state := gooanda.NewAccountState(newTestAccountStateClient(t))
+	var changes []gooanda.AccountStateChange
+	state.OnChange(func(change gooanda.AccountStateChange) {
+		changes = append(changes, change)
+	})
+	ctx := context.Background()
+	if err := state.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	loaded := state.Snapshot()
+	if orderIDs(loaded.Orders) != "5" || len(loaded.Trades) != 1 || loaded.LastTransactionID != "6" {
+		t.Fatalf("loaded = %+v", loaded)
+	}
+
+	if err := state.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	account := state.Snapshot()
+	if len(account.Orders) != 0 || account.PendingOrderCount != 0 {
+		t.Errorf("orders = %v, want the limit order filled", orderIDs(account.Orders))
+	}
+	if len(account.Trades) != 2 || account.Trades[1].ID != "7" || !account.Trades[1].UnrealizedPL.Equal(dec("1.5")) {
+		t.Errorf("trades = %+v", account.Trades)
+	}
+	if len(account.Positions) != 2 || !account.Positions[1].Long.UnrealizedPL.Equal(dec("1.5")) || account.OpenPositionCount != 2 {
+		t.Errorf("positions = %+v", account.Positions)
+	}
+	if !account.Balance.Equal(dec("99999.8")) || !account.NAV.Equal(dec("100001.3")) || !account.MarginUsed.Equal(dec("4.88")) || account.LastTransactionID != "7" {
+		t.Errorf("summary = %+v", account.AccountSummary)
+	}
+	if len(loaded.Orders) != 1 || len(loaded.Trades) != 1 {
+		t.Errorf("loaded snapshot modified by the poll, %+v", loaded)
+	}
+
+	if err := state.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	account = state.Snapshot()
+	if tsl, ok := account.Orders[0].(*gooanda.TrailingStopLossOrder); !ok || !tsl.TrailingStopValue.Equal(dec("1.2172")) {
+		t.Errorf("orders = %#v", account.Orders)
+	}
+	if len(account.Trades) != 1 || account.Trades[0].ID != "7" || account.OpenPositionCount != 1 {
+		t.Errorf("trades = %+v, openPositionCount = %v", account.Trades, account.OpenPositionCount)
+	}
+	if !account.Balance.Equal(dec("100000.1")) || !account.PL.Equal(dec("0.3")) || account.LastTransactionID != "9" {
+		t.Errorf("summary = %+v", account.AccountSummary)
+	}
+
+	if err := state.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if tsl := state.Snapshot().Orders[0].(*gooanda.TrailingStopLossOrder); !tsl.TrailingStopValue.Equal(dec("1.2177")) {
+		t.Errorf("trailingStopValue = %v", tsl.TrailingStopValue)
+	}
+	if tsl := account.Orders[0].(*gooanda.TrailingStopLossOrder); !tsl.TrailingStopValue.Equal(dec("1.2172")) {
+		t.Errorf("previous snapshot order modified, trailingStopValue = %v", tsl.TrailingStopValue)
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("changes = %v, want 3", len(changes))
+	}
+	if changes[0].Account.LastTransactionID != "7" || len(changes[0].Changes.Transactions) != 1 || !changes[2].State.NAV.Equal(dec("100002.1")) {
+		t.Errorf("changes = %+v", changes)
+	}
+}
+
+func TestAccountStateBalanceFromState(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/accounts/101-001-1-001" {
+			fmt.Fprint(w, testAccount)
+			return
+		}
+		// the balance of the state includes a financing not in the transactions.
+		fmt.Fprint(w, `{"changes":{"transactions":[{"id":"7","type":"ORDER_FILL","instrument":"USD_JPY","units":"-10","accountBalance":"100000.1000"}]},`+
+			`"state":{"NAV":"100000.0500","balance":"100000.0500","pl":"0.0500","resettablePL":"0.0500","financing":"-0.0500"},"lastTransactionID":"7"}`)
+	})
+	state := gooanda.NewAccountState(client)
+	ctx := context.Background()
+	if err := state.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if account := state.Snapshot(); !account.Balance.Equal(dec("100000.05")) || !account.PL.Equal(dec("0.05")) || !account.ResettablePL.Equal(dec("0.05")) || !account.Financing.Equal(dec("-0.05")) {
+		t.Errorf("summary = %+v, want the balance and the profits of the state", account.AccountSummary)
+	}
+}
+
+func TestAccountStatePollFromCallback(t *testing.T) {
+	state := gooanda.NewAccountState(newTestAccountStateClient(t))
+	ctx := context.Background()
+	if err := state.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := false
+	state.OnChange(func(change gooanda.AccountStateChange) {
+		if reloaded {
+			return
+		}
+		reloaded = true
+		if err := state.Load(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+	errs := make(chan error, 1)
+	go func() { errs <- state.Poll(ctx) }()
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the poll, the callback is blocked")
+	}
+	if account := state.Snapshot(); !reloaded || account.LastTransactionID != "6" {
+		t.Errorf("lastTransactionID = %v, want the account reloaded by the callback", account.LastTransactionID)
+	}
+}
+
+func TestAccountStateRun(t *testing.T) {
+	state := gooanda.NewAccountState(newTestAccountStateClient(t), gooanda.WithPollInterval(time.Millisecond))
+	var mu sync.Mutex
+	polled := map[string]bool{}
+	done := make(chan struct{})
+	state.OnChange(func(change gooanda.AccountStateChange) {
+		mu.Lock()
+		defer mu.Unlock()
+		polled[change.Account.LastTransactionID] = true
+		if polled["7"] && polled["9"] && len(polled) == 2 && done != nil {
+			close(done)
+			done = nil
+		}
+	})
+	state.OnError(func(err error) {
+		t.Errorf("poll error, %v", err)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() { errs <- state.Run(ctx) }()
+
+	wait := done
+	timeout := time.After(5 * time.Second)
+loop:
+	for {
+		select {
+		case <-wait:
+			break loop
+		case <-timeout:
+			t.Fatal("timeout waiting for the changes")
+		default:
+			state.Snapshot()
+			time.Sleep(time.Millisecond)
+		}
+	}
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if account := state.Snapshot(); account.LastTransactionID != "9" || len(account.Trades) != 1 {
+		t.Errorf("account = %+v", account)
+	}
+}
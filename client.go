@@ -0,0 +1,132 @@
+package gooanda
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kokweikhong/gooanda/endpoint"
+)
+
+// Client is the connection to the OANDA v20 API shared by all services.
+// The token, environment, default account and http settings are
+// configured once when creating the client.
+type Client struct {
+	token       string
+	environment endpoint.Environment
+	accountID   string
+	httpClient  *http.Client
+	// streamClient is httpClient without timeout for long-lived streams.
+	streamClient  *http.Client
+	transportOpts []func(*http.Transport)
+	restTimeout   time.Duration
+	streamTimeout time.Duration
+	retryPolicy   RetryPolicy
+	middleware    []Middleware
+	// datetimeFormat is the Accept-Datetime-Format header, empty to not send it.
+	datetimeFormat string
+	compression    bool
+
+	heartbeatTimeout time.Duration
+	streamReconnect  bool
+	reconnectPolicy  RetryPolicy
+
+	rateLimit       *RateLimit
+	streamRateLimit *RateLimit
+	rateLimitError  bool
+	limiter         *tokenLimiter
+
+	account     *AccountService
+	instrument  *InstrumentService
+	pricing     *PricingService
+	order       *OrderService
+	trade       *TradeService
+	position    *PositionService
+	transaction *TransactionService
+}
+
+type ClientOpts func(*Client)
+
+// NewClient is to create a client for the practice environment,
+// use the client options to change the default settings.
+func NewClient(token string, opts ...ClientOpts) *Client {
+	c := &Client{
+		token:       token,
+		environment: endpoint.Practice,
+		retryPolicy: DefaultRetryPolicy,
+		restTimeout: DefaultRESTTimeout,
+		compression: true,
+
+		heartbeatTimeout: DefaultStreamHeartbeatTimeout,
+		streamReconnect:  true,
+		reconnectPolicy:  DefaultStreamReconnectPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.initHTTPClients()
+	c.limiter = sharedLimiter(token, c.rateLimit, c.streamRateLimit)
+	c.initServices()
+	return c
+}
+
+// WithEnvironment is the environment used to build every endpoint. [default=Practice]
+func WithEnvironment(env endpoint.Environment) ClientOpts {
+	return func(c *Client) { c.environment = env }
+}
+
+// WithLive is to use the live environment instead of the practice environment.
+func WithLive() ClientOpts {
+	return func(c *Client) { c.environment = endpoint.Live }
+}
+
+// WithAccountID is the default account used by every account related request.
+func WithAccountID(accountID string) ClientOpts {
+	return func(c *Client) { c.accountID = accountID }
+}
+
+func (c *Client) initServices() {
+	conn := connection{client: c}
+	c.account = &AccountService{connection: conn}
+	c.instrument = &InstrumentService{connection: conn}
+	c.pricing = &PricingService{connection: conn}
+	c.order = &OrderService{connection: conn}
+	c.trade = &TradeService{connection: conn}
+	c.position = &PositionService{connection: conn}
+	c.transaction = &TransactionService{connection: conn}
+}
+
+// AccountID is the default account of the client.
+func (c *Client) AccountID() string { return c.accountID }
+
+// Environment is the environment of the client.
+func (c *Client) Environment() endpoint.Environment { return c.environment }
+
+// ForAccount is to get a copy of the client using accountID as the default
+// account, the copy shares the token, environment and http client.
+func (c *Client) ForAccount(accountID string) *Client {
+	cp := *c
+	cp.accountID = accountID
+	cp.initServices()
+	return &cp
+}
+
+// Accounts is the service for the ACCOUNT API.
+func (c *Client) Accounts() *AccountService { return c.account }
+
+// Instruments is the service for the INSTRUMENT API.
+func (c *Client) Instruments() *InstrumentService { return c.instrument }
+
+// Pricing is the service for the PRICING API.
+func (c *Client) Pricing() *PricingService { return c.pricing }
+
+// Orders is the service for the ORDER API.
+func (c *Client) Orders() *OrderService { return c.order }
+
+// Trades is the service for the TRADE API.
+func (c *Client) Trades() *TradeService { return c.trade }
+
+// Positions is the service for the POSITION API.
+func (c *Client) Positions() *PositionService { return c.position }
+
+// Transactions is the service for the TRANSACTION API.
+func (c *Client) Transactions() *TransactionService { return c.transaction }
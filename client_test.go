@@ -0,0 +1,131 @@
+package gooanda_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+	"github.com/kokweikhong/gooanda/endpoint"
+)
+
+// newTestEnvironment is to start a local test server for the handler.
+func newTestEnvironment(t testing.TB, handler http.HandlerFunc) endpoint.Environment {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return endpoint.Custom(srv.URL, srv.URL)
+}
+
+// newTestClient is to create a client pointing to a local test server.
+func newTestClient(t testing.TB, handler http.HandlerFunc, opts ...gooanda.ClientOpts) *gooanda.Client {
+	opts = append([]gooanda.ClientOpts{
+		gooanda.WithEnvironment(newTestEnvironment(t, handler)),
+		gooanda.WithAccountID("101-001-1-001"),
+	}, opts...)
+	return gooanda.NewClient("token", opts...)
+}
+
+// dec is the Decimal of s.
+func dec(s string) gooanda.Decimal {
+	return gooanda.MustDecimal(s)
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/candles"):
+			instrument := strings.Split(r.URL.Path, "/")[3]
+			fmt.Fprintf(w, `{"instrument":%q,"granularity":%q,"candles":[]}`,
+				instrument, r.URL.Query().Get("granularity"))
+		case strings.HasSuffix(r.URL.Path, "/orders"):
+			// echo the order as the transaction creating it.
+			body, _ := ioutil.ReadAll(r.Body)
+			body = bytes.Replace(body, []byte(`{"order":`), []byte(`{"orderCreateTransaction":`), 1)
+			w.Write(bytes.Replace(body, []byte(`"type":"MARKET"`), []byte(`"type":"MARKET_ORDER"`), 1))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	instruments := []string{"EUR_USD", "USD_JPY", "GBP_USD", "AUD_USD", "USD_CAD"}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		instrument := instruments[i%len(instruments)]
+		granularity := fmt.Sprintf("M%d", i%5+1)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			in := client.Instruments()
+			data, err := in.GetCandles(instrument, in.Query.WithGranularity(granularity))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if data.Instrument != instrument || data.Granularity != granularity {
+				t.Errorf("GetCandles(%v, %v) = %v, %v", instrument, granularity,
+					data.Instrument, data.Granularity)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			resp, err := client.Orders().MarketOrderRequest(instrument, dec("100"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			created, ok := resp.OrderCreateTransaction.(*gooanda.MarketOrderTransaction)
+			if !ok {
+				t.Errorf("orderCreateTransaction = %T", resp.OrderCreateTransaction)
+				return
+			}
+			if created.Instrument != instrument {
+				t.Errorf("MarketOrderRequest(%v) sent %v", instrument, created.Instrument)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRequestContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.Pricing().GetPricingInformationContext(ctx, []string{"EUR_USD"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v after the deadline", elapsed)
+	}
+}
+
+func TestServiceTypes(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-002/openTrades" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"trades":[],"lastTransactionID":"1"}`)
+	})
+	// a service is kept with the account of the client it is got from.
+	var trades *gooanda.TradeService = client.ForAccount("101-001-1-002").Trades()
+	if _, err := trades.GetOpenTradeList(); err != nil {
+		t.Fatal(err)
+	}
+}
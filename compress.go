@@ -0,0 +1,66 @@
+package gooanda
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WithoutCompression is to not request gzip compressed responses, neither
+// by the client nor by its transport. A RoundTripper of WithHTTPClient
+// which is not an *http.Transport may still request them.
+func WithoutCompression() ClientOpts {
+	return func(c *Client) {
+		c.compression = false
+		withTransport(func(t *http.Transport) { t.DisableCompression = true })(c)
+	}
+}
+
+// setAcceptEncoding is to request a gzip compressed response, it is set
+// explicitly so the response is compressed whatever the http client and
+// is decompressed by gunzip.
+func (c *Client) setAcceptEncoding(req *http.Request) {
+	if c.compression {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+}
+
+// gunzip is to decompress the gzip compressed responses of next, the
+// body is decompressed as it is read so a stream is not buffered.
+func gunzip(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		resp, err := next(req)
+		if err != nil || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+			return resp, err
+		}
+		resp.Body = &gzipBody{body: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+		return resp, nil
+	}
+}
+
+// gzipBody is a gzip compressed body, the gzip reader is created by the
+// first read as it blocks until the gzip header is received.
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil && b.err == nil {
+		b.zr, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
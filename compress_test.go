@@ -0,0 +1,142 @@
+package gooanda_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+// testCandles is a candles response with n hourly candles.
+func testCandles(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"instrument":"EUR_USD","granularity":"H1","candles":[`)
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		price := 1.2 + float64(i%500)/10000
+		fmt.Fprintf(&b, `{"complete":true,"volume":%d,"time":%q,"mid":{"o":"%.5f","h":"%.5f","l":"%.5f","c":"%.5f"}}`,
+			100+i%37, start.Add(time.Duration(i)*time.Hour).Format(time.RFC3339Nano),
+			price, price+0.0010, price-0.0010, price+0.0005)
+	}
+	b.WriteString(`]}`)
+	return b.Bytes()
+}
+
+func gzipBytes(data []byte) []byte {
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	zw.Write(data)
+	zw.Close()
+	return b.Bytes()
+}
+
+// compressedHandler is to serve the fixture gzip compressed when accepted,
+// the bytes written are added to written.
+func compressedHandler(fixture []byte, written *int64) http.HandlerFunc {
+	compressed := gzipBytes(fixture)
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := fixture
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			body = compressed
+		}
+		atomic.AddInt64(written, int64(len(body)))
+		w.Write(body)
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	var written int64
+	fixture := testCandles(100)
+	client := newTestClient(t, compressedHandler(fixture, &written))
+	candles, err := client.Instruments().GetCandles("EUR_USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles.Candles) != 100 || candles.Candles[99].Mid.Close.IsZero() {
+		t.Errorf("decoded %d candles", len(candles.Candles))
+	}
+	if written >= int64(len(fixture)) {
+		t.Errorf("written %d bytes, want less than %d", written, len(fixture))
+	}
+}
+
+func TestGzipStream(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		for _, msg := range []string{testPrice, testHeartbeat} {
+			fmt.Fprintln(zw, msg)
+			zw.Flush()
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.Pricing().StreamPrices(ctx, []string{"EUR_USD"})
+	for _, want := range []string{"PRICE", "HEARTBEAT"} {
+		select {
+		case event := <-events:
+			if event.Type != want {
+				t.Errorf("event = %v, want %v", event.Type, want)
+			}
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the gzip compressed stream")
+		}
+	}
+}
+
+func TestWithoutCompressionWithHTTPClient(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if encoding := r.Header.Get("Accept-Encoding"); encoding != "" {
+			t.Errorf("Accept-Encoding = %q, want none", encoding)
+		}
+		fmt.Fprint(w, `{"accounts":[]}`)
+	}, gooanda.WithoutCompression(), gooanda.WithHTTPClient(&http.Client{}))
+	if _, err := client.Accounts().GetAccountList(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkGetCandlesCompression(b *testing.B) {
+	fixture := testCandles(5000)
+	for _, bc := range []struct {
+		name string
+		opts []gooanda.ClientOpts
+	}{
+		{"Gzip", nil},
+		{"Identity", []gooanda.ClientOpts{gooanda.WithoutCompression()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var written int64
+			env := newTestEnvironment(b, compressedHandler(fixture, &written))
+			opts := append([]gooanda.ClientOpts{gooanda.WithEnvironment(env),
+				gooanda.WithRateLimit(gooanda.RateLimit{})}, bc.opts...)
+			client := gooanda.NewClient(b.Name(), opts...)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.Instruments().GetCandles("EUR_USD"); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&written))/float64(b.N), "wire-B/op")
+		})
+	}
+}
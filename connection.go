@@ -3,40 +3,44 @@ package gooanda
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
+// connection is embedded by every service, it only refers to the client
+// so a service is safe for concurrent use.
 type connection struct {
-	endpoint string
+	client *Client
+}
+
+// request is the state of a single api call, it is built per call
+// instead of being stored on the service.
+type request struct {
 	method   string
-	token    string
+	endpoint string
 	data     []byte
 }
 
-func (co *connection) connect() ([]byte, error) {
-	client := &http.Client{Timeout: 5 * time.Second}
-	var buffer bytes.Buffer
-	buffer.WriteString("Bearer ")
-	buffer.WriteString(co.token)
-	auth := buffer.String()
-	req, err := http.NewRequest(co.method, co.endpoint, bytes.NewBuffer(co.data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to request api from %v, %v", co.endpoint, err)
-	}
-	// req.Header.Set("User-Agent", "v20-golang/0.1")
-	req.Header.Set("Authorization", auth)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+// getEndpoint is to get the endpoint from the environment of the client.
+func (co *connection) getEndpoint(ep interface{}) string {
+	return co.client.environment.GetEndpoint(ep)
+}
+
+func (co *connection) connect(ctx context.Context, r *request) ([]byte, error) {
+	resp, err := co.do(ctx, r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to request api after set token, %v", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 	var body []byte
-	if strings.Contains(co.endpoint, "stream") {
+	if isStream(resp.Request.URL) {
 		body, err = streamApiConnect(resp)
 		if err != nil {
 			return nil, err
@@ -46,8 +50,89 @@ func (co *connection) connect() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		if md := metadataFrom(ctx); md != nil {
+			md.setBody(body)
+		}
 	}
-	return body, nil
+	return co.client.redactBytes(body), nil
+}
+
+// do is to send the request and retry the transient failures allowed by
+// the retry policy of the client. The response has a 2xx status code.
+func (co *connection) do(ctx context.Context, r *request) (*http.Response, error) {
+	return co.doAttempts(ctx, r, co.client.retryPolicy.attempts(ctx, r.method))
+}
+
+// doAttempts is do with the number of attempts to send the request.
+func (co *connection) doAttempts(ctx context.Context, r *request, attempts int) (*http.Response, error) { // {{{
+	policy := co.client.retryPolicy
+	md := metadataFrom(ctx)
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp, err := co.send(ctx, r)
+		if attempt < attempts && retryable(ctx, resp, err) {
+			wait := policy.backoff(attempt, resp)
+			if resp != nil {
+				io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("failed to request api after %d attempts, %w", attempt, ctx.Err())
+			case <-timer.C:
+			}
+			continue
+		}
+		if err != nil {
+			return nil, co.client.redact(err)
+		}
+		capture := md != nil && !isStream(resp.Request.URL)
+		if capture {
+			md.setResponse(resp, start, attempt)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			defer resp.Body.Close()
+			apiErr := newAPIError(resp)
+			if capture {
+				md.LastTransactionID = apiErr.LastTransactionID
+			}
+			return nil, co.client.redact(apiErr)
+		}
+		return resp, nil
+	}
+} // }}}
+
+// send is to send a single attempt of the request, its errors are
+// redacted by do.
+func (co *connection) send(ctx context.Context, r *request) (*http.Response, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("Bearer ")
+	buffer.WriteString(co.client.token)
+	auth := buffer.String()
+	req, err := http.NewRequestWithContext(ctx, r.method, r.endpoint, bytes.NewReader(r.data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to request api from %v, %v", r.endpoint, err)
+	}
+	// req.Header.Set("User-Agent", "v20-golang/0.1")
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Content-Type", "application/json")
+	co.client.setDatetimeFormat(req)
+	co.client.setAcceptEncoding(req)
+	stream := isStream(req.URL)
+	if err = co.client.waitRateLimit(ctx, stream); err != nil {
+		return nil, err
+	}
+	roundTrip := co.client.httpClient.Do
+	if stream {
+		roundTrip = co.client.doStream
+	}
+	resp, err := co.client.wrapMiddleware(gunzip(roundTrip))(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request api after set token, %w", err)
+	}
+	return resp, nil
 }
 
 func restApiConnect(resp *http.Response) ([]byte, error) {
@@ -66,3 +151,35 @@ func streamApiConnect(resp *http.Response) ([]byte, error) {
 	}
 	return body, nil
 }
+
+// isStream is to check whether the url is served by the streaming api.
+func isStream(u *url.URL) bool {
+	return strings.HasSuffix(u.Path, "/stream")
+}
+
+// connectJSON is to send the request and decode the json response into v
+// directly from the body. The body is read whole only to capture the
+// metadata of the response.
+func (co *connection) connectJSON(ctx context.Context, r *request, v interface{}) error {
+	if metadataFrom(ctx) != nil {
+		data, err := co.connect(ctx, r)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("failed to unmarshal %s to %T, %v", data, v, err)
+		}
+		return nil
+	}
+	resp, err := co.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err = decodeJSON(resp.Body, v); err != nil {
+		return co.client.redact(fmt.Errorf("failed to decode response of %v to %T, %v", resp.Request.URL.Path, v, err))
+	}
+	// the rest of the body is read so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
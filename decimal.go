@@ -0,0 +1,305 @@
+package gooanda
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, used for the prices, units and amounts
+// of the API instead of float64. It keeps the digits it is decoded from, so
+// that "1.22010" is encoded back as "1.22010", and it is immutable: the
+// arithmetic returns a new Decimal.
+//
+// The zero Decimal is unset, it is 0 in the arithmetic and is encoded as
+// null: an optional field missing from a response is an unset Decimal, use
+// IsSet to tell it from zero. The optional fields of the requests are
+// *Decimal so that they are left out when nil. Decimals are comparable with
+// == only for the same digits, use Cmp or Equal to compare their values.
+type Decimal struct {
+	s string
+}
+
+// NewDecimal is to create the Decimal of value * 10^exp.
+func NewDecimal(value int64, exp int32) Decimal {
+	return newDecimal(big.NewInt(value), -exp)
+}
+
+// DecimalFromInt is to create the Decimal of an integer.
+func DecimalFromInt(value int64) Decimal {
+	return NewDecimal(value, 0)
+}
+
+// DecimalFromFloat is to create the Decimal of the shortest representation
+// of value, 1.1 is "1.1" and not "1.100000000000000088817841970012523".
+func DecimalFromFloat(value float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		panic(fmt.Sprintf("gooanda: decimal from float %v, %v", value, err))
+	}
+	return d
+}
+
+// maxDecimalScale is the largest number of digits after the decimal point,
+// and of zeros added by an exponent, of a parsed Decimal. It bounds the
+// memory and the time taken by a number from an untrusted payload.
+const maxDecimalScale = 64
+
+// ParseDecimal is to parse a decimal number such as "-1.22010", an
+// exponent such as "1.5e-3" is accepted.
+func ParseDecimal(s string) (Decimal, error) { // {{{
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("failed to parse decimal %q, invalid exponent", s)
+		}
+		mantissa = s[:i]
+	}
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	integer, fraction := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		integer, fraction = mantissa[:i], mantissa[i+1:]
+	}
+	digits := integer + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("failed to parse decimal %q", s)
+	}
+	unscaled, _ := new(big.Int).SetString(sign+digits, 10)
+	scale := int64(len(fraction)) - exp
+	if scale < -maxDecimalScale || scale > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("failed to parse decimal %q, scale out of range", s)
+	}
+	return newDecimal(unscaled, int32(scale)), nil
+} // }}}
+
+// MustDecimal is ParseDecimal panicking on an invalid number, it is to
+// write the constants of a program.
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic("gooanda: " + err.Error())
+	}
+	return d
+}
+
+// newDecimal is to create the Decimal of unscaled * 10^-scale, a negative
+// scale is written with zeros as the API does not use exponents.
+func newDecimal(unscaled *big.Int, scale int32) Decimal { // {{{
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	digits := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if pad := int(scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(scale)] + "." + digits[len(digits)-int(scale):]
+	}
+	if unscaled.Sign() < 0 {
+		digits = "-" + digits
+	}
+	return Decimal{s: digits}
+} // }}}
+
+// pow10 is 10^n.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// parts is the unscaled value and the scale of d.
+func (d Decimal) parts() (*big.Int, int32) {
+	if d.s == "" {
+		return new(big.Int), 0
+	}
+	integer, fraction := d.s, ""
+	if i := strings.IndexByte(d.s, '.'); i >= 0 {
+		integer, fraction = d.s[:i], d.s[i+1:]
+	}
+	unscaled, _ := new(big.Int).SetString(integer+fraction, 10)
+	return unscaled, int32(len(fraction))
+}
+
+// aligned is the unscaled values of d and e with the same scale.
+func (d Decimal) aligned(e Decimal) (*big.Int, *big.Int, int32) {
+	x, xs := d.parts()
+	y, ys := e.parts()
+	switch {
+	case xs < ys:
+		x.Mul(x, pow10(ys-xs))
+		xs = ys
+	case ys < xs:
+		y.Mul(y, pow10(xs-ys))
+	}
+	return x, y, xs
+}
+
+// String is the digits of d, "0" if d is unset.
+func (d Decimal) String() string {
+	if d.s == "" {
+		return "0"
+	}
+	return d.s
+}
+
+// IsSet is whether d was set, a Decimal decoded from null or from a missing
+// field is not set.
+func (d Decimal) IsSet() bool {
+	return d.s != ""
+}
+
+// Float64 is the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Scale is the number of digits of d after the decimal point.
+func (d Decimal) Scale() int {
+	_, scale := d.parts()
+	return int(scale)
+}
+
+// Sign is -1, 0 or 1 as d is negative, zero or positive.
+func (d Decimal) Sign() int {
+	switch {
+	case strings.HasPrefix(d.s, "-"):
+		return -1
+	case strings.Trim(d.s, "0.") == "":
+		return 0
+	}
+	return 1
+}
+
+// IsZero is whether d is zero, an unset Decimal is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp is -1, 0 or 1 as d is less than, equal to or greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	x, y, _ := d.aligned(e)
+	return x.Cmp(y)
+}
+
+// Equal is whether d and e have the same value, whatever their digits.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Add is d + e.
+func (d Decimal) Add(e Decimal) Decimal {
+	x, y, scale := d.aligned(e)
+	return newDecimal(x.Add(x, y), scale)
+}
+
+// Sub is d - e.
+func (d Decimal) Sub(e Decimal) Decimal {
+	x, y, scale := d.aligned(e)
+	return newDecimal(x.Sub(x, y), scale)
+}
+
+// Mul is d * e, with the digits of both after the decimal point.
+func (d Decimal) Mul(e Decimal) Decimal {
+	x, xs := d.parts()
+	y, ys := e.parts()
+	return newDecimal(x.Mul(x, y), xs+ys)
+}
+
+// Quo is d / e rounded half away from zero to places digits after the
+// decimal point, it panics if e is zero.
+func (d Decimal) Quo(e Decimal, places int) Decimal { // {{{
+	if e.IsZero() {
+		panic("gooanda: decimal division by zero")
+	}
+	x, xs := d.parts()
+	y, ys := e.parts()
+	// x/10^xs / y/10^ys = x*10^(places+1+ys-xs) / y / 10^(places+1)
+	shift := int32(places) + 1 + ys - xs
+	if shift >= 0 {
+		x.Mul(x, pow10(shift))
+	} else {
+		y.Mul(y, pow10(-shift))
+	}
+	return newDecimal(x.Quo(x, y), int32(places)+1).Round(places)
+} // }}}
+
+// Neg is -d.
+func (d Decimal) Neg() Decimal {
+	x, scale := d.parts()
+	return newDecimal(x.Neg(x), scale)
+}
+
+// Abs is the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	if d.Sign() < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Round is d rounded half away from zero to places digits after the decimal
+// point, d is returned as is if it does not have more digits.
+func (d Decimal) Round(places int) Decimal { // {{{
+	x, scale := d.parts()
+	if int(scale) <= places {
+		return d
+	}
+	divisor := pow10(scale - int32(places))
+	quotient, remainder := new(big.Int).QuoRem(x, divisor, new(big.Int))
+	// |remainder| * 2 >= divisor rounds away from zero.
+	if remainder.Abs(remainder).Lsh(remainder, 1).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(x.Sign())))
+	}
+	return newDecimal(quotient, int32(places))
+} // }}}
+
+// Truncate is d with the digits after places digits after the decimal
+// point dropped.
+func (d Decimal) Truncate(places int) Decimal {
+	x, scale := d.parts()
+	if int(scale) <= places {
+		return d
+	}
+	return newDecimal(x.Quo(x, pow10(scale-int32(places))), int32(places))
+}
+
+// MarshalJSON is to encode d as a JSON string as the API does, an unset
+// Decimal is encoded as null.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.s == "" {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + d.s + `"`), nil
+}
+
+// UnmarshalJSON is to decode a JSON string or number, null and an empty
+// string are decoded as an unset Decimal.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	parsed, err := ParseDecimal(string(data))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// optionalDecimal is a pointer to d, nil if d is unset, for the optional
+// fields of the requests.
+func optionalDecimal(d Decimal) *Decimal {
+	if !d.IsSet() {
+		return nil
+	}
+	return &d
+}
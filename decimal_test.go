@@ -0,0 +1,139 @@
+package gooanda_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"1.22010", "1.22010"},
+		{"-0.2000", "-0.2000"},
+		{"+100", "100"},
+		{"0100.5", "100.5"},
+		{".5", "0.5"},
+		{"-0", "0"},
+		{"1.5e-3", "0.0015"},
+		{"12E2", "1200"},
+		{"1e64", "1" + strings.Repeat("0", 64)},
+	}
+	for _, tt := range tests {
+		got, err := gooanda.ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("parse %q, %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parse %q = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	invalid := []string{"", "-", ".", "1.2.3", "1,5", "abc", "1e", "NaN",
+		"1e2000000000", "1e-2000000000", "1e65", "0." + strings.Repeat("1", 65)}
+	for _, in := range invalid {
+		if got, err := gooanda.ParseDecimal(in); err == nil {
+			t.Errorf("parse %q = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  gooanda.Decimal
+		want string
+	}{
+		{"add", dec("1.1").Add(dec("2.2")), "3.3"},
+		{"add scales", dec("1.22010").Add(dec("0.5")), "1.72010"},
+		{"sub", dec("100000.0000").Sub(dec("0.2000")), "99999.8000"},
+		{"sub negative", dec("0.1").Sub(dec("0.3")), "-0.2"},
+		{"mul", dec("1.22010").Mul(dec("-100")), "-122.01000"},
+		{"quo", dec("1").Quo(dec("3"), 5), "0.33333"},
+		{"quo round", dec("2").Quo(dec("3"), 5), "0.66667"},
+		{"quo negative", dec("-1").Quo(dec("8"), 2), "-0.13"},
+		{"neg", dec("1.5").Neg(), "-1.5"},
+		{"abs", dec("-1.5").Abs(), "1.5"},
+		{"unset", gooanda.Decimal{}.Add(dec("1.5")), "1.5"},
+		{"new", gooanda.NewDecimal(122010, -5), "1.22010"},
+		{"new exp", gooanda.NewDecimal(12, 2), "1200"},
+		{"float", gooanda.DecimalFromFloat(1.1), "1.1"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%v = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecimalCompare(t *testing.T) {
+	if !dec("1.10").Equal(dec("1.1")) || dec("1.10") == dec("1.1") {
+		t.Error("1.10 and 1.1 must have the same value but not the same digits")
+	}
+	if dec("1.2").Cmp(dec("1.19999")) != 1 || dec("-2").Cmp(dec("1")) != -1 {
+		t.Error("wrong comparison")
+	}
+	if !dec("0.0000").IsZero() || !(gooanda.Decimal{}).IsZero() || (gooanda.Decimal{}).IsSet() {
+		t.Error("wrong zero")
+	}
+	if dec("-0.5").Sign() != -1 || dec("0.00").Sign() != 0 || dec("0.01").Sign() != 1 {
+		t.Error("wrong sign")
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		got  gooanda.Decimal
+		want string
+	}{
+		{dec("1.234565").Round(5), "1.23457"},
+		{dec("1.234564").Round(5), "1.23456"},
+		{dec("-1.234565").Round(5), "-1.23457"},
+		{dec("1.2").Round(5), "1.2"},
+		{dec("0.5").Round(0), "1"},
+		{dec("150").Round(-2), "200"},
+		{dec("1.99").Truncate(1), "1.9"},
+		{dec("-1.99").Truncate(0), "-1"},
+	}
+	for i, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%d: got %v, want %v", i, tt.got, tt.want)
+		}
+	}
+
+	eurusd := gooanda.Instrument{Name: "EUR_USD", DisplayPrecision: 5, TradeUnitsPrecision: 0}
+	if got := eurusd.RoundPrice(gooanda.DecimalFromFloat(1.1 * 1.0000001)); got.String() != "1.10000" {
+		t.Errorf("price = %v, want 1.10000", got)
+	}
+	if got := eurusd.RoundUnits(dec("-100.9")); got.String() != "-100" {
+		t.Errorf("units = %v, want -100", got)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		Price  gooanda.Decimal  `json:"price"`
+		Units  gooanda.Decimal  `json:"units"`
+		Bound  gooanda.Decimal  `json:"bound"`
+		Amount *gooanda.Decimal `json:"amount,omitempty"`
+	}
+	if err := json.Unmarshal([]byte(`{"price":"1.22010","units":-100,"bound":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Price.String() != "1.22010" || v.Units.String() != "-100" || v.Bound.IsSet() {
+		t.Errorf("decoded = %+v", v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"price":"1.22010","units":"-100","bound":null}` {
+		t.Errorf("encoded = %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"price":"1.2.3"}`), &v); err == nil {
+		t.Error("decoded an invalid price")
+	}
+}
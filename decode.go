@@ -0,0 +1,124 @@
+package gooanda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// decodeJSON is to decode the json object read from r into v, a pointer
+// to a struct. The elements of the top level arrays, e.g. the candles or
+// the transactions, are decoded one at a time from r so the body is never
+// held in memory as a whole, the other fields are decoded together.
+// A v decoding itself is decoded as a whole.
+func decodeJSON(r io.Reader, v interface{}) error { // {{{
+	rv := reflect.ValueOf(v)
+	if _, ok := v.(json.Unmarshaler); ok || rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return json.NewDecoder(r).Decode(v)
+	}
+	st := rv.Elem()
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	var rest bytes.Buffer
+	rest.WriteByte('{')
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		if field, ok := sliceField(st, key); ok {
+			if err = decodeArray(dec, field); err != nil {
+				return fmt.Errorf("failed to decode %v, %v", key, err)
+			}
+			continue
+		}
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return err
+		}
+		if rest.Len() > 1 {
+			rest.WriteByte(',')
+		}
+		rest.WriteString(strconv.Quote(key))
+		rest.WriteByte(':')
+		rest.Write(raw)
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+	rest.WriteByte('}')
+	return json.Unmarshal(rest.Bytes(), v)
+} // }}}
+
+// decodeArray is to decode the json array of dec into the slice field,
+// one element at a time. A null value leaves the field empty.
+func decodeArray(dec *json.Decoder, field reflect.Value) error {
+	token, err := dec.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("got %v, want an array", token)
+	}
+	// the slice is grown by doubling and the elements are decoded in place.
+	slice := reflect.MakeSlice(field.Type(), 0, 0)
+	for n := 0; dec.More(); n++ {
+		if n == slice.Cap() {
+			grown := reflect.MakeSlice(field.Type(), n, 2*n+16)
+			reflect.Copy(grown, slice)
+			slice = grown
+		}
+		slice = slice.Slice(0, n+1)
+		if err = dec.Decode(slice.Index(n).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	_, err = dec.Token()
+	return err
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// sliceField is to get the slice field of st for the json key, a slice
+// decoding itself, e.g. Orders, is decoded with the other fields.
+func sliceField(st reflect.Value, key string) (reflect.Value, bool) {
+	t := st.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Type.Kind() != reflect.Slice || f.Type.Elem().Kind() == reflect.Uint8 ||
+			reflect.PtrTo(f.Type).Implements(unmarshalerType) {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return st.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// expectDelim is to read the delim from dec.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if got, ok := token.(json.Delim); !ok || got != delim {
+		return fmt.Errorf("got %v, want %v", token, delim)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package gooanda_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+// testTransactionPages is a transactions response with n pages.
+func testTransactionPages(n int) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `{"from":"2021-01-01T00:00:00.000000000Z","to":"2021-06-01T00:00:00.000000000Z","pageSize":100,"count":%d,"pages":[`, n*100)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `"https://api-fxpractice.oanda.com/v3/accounts/101-001-1-001/transactions/idrange?from=%d&to=%d"`, i*100+1, i*100+100)
+	}
+	fmt.Fprintf(&b, `],"lastTransactionID":"%d"}`, n*100)
+	return b.Bytes()
+}
+
+func TestDecodeGetCandles(t *testing.T) {
+	fixture := testCandles(1000)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) { w.Write(fixture) })
+	want := &gooanda.InstrumentCandles{}
+	if err := json.Unmarshal(fixture, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.Instruments().GetCandles("EUR_USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("streaming decoded candles differ from json.Unmarshal")
+	}
+	if got.Instrument != "EUR_USD" || len(got.Candles) != 1000 {
+		t.Errorf("instrument = %v, %d candles", got.Instrument, len(got.Candles))
+	}
+}
+
+func BenchmarkDecodeGetCandles(b *testing.B) {
+	fixture := testCandles(5000)
+	env := newTestEnvironment(b, func(w http.ResponseWriter, r *http.Request) { w.Write(fixture) })
+	client := gooanda.NewClient(b.Name(), gooanda.WithEnvironment(env),
+		gooanda.WithRateLimit(gooanda.RateLimit{}), gooanda.WithoutCompression())
+	b.ReportAllocs()
+	b.SetBytes(int64(len(fixture)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Instruments().GetCandles("EUR_USD"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeGetCandlesReadAll is the baseline of BenchmarkDecodeGetCandles,
+// the metadata is captured so the body is read whole and unmarshalled.
+func BenchmarkDecodeGetCandlesReadAll(b *testing.B) {
+	fixture := testCandles(5000)
+	env := newTestEnvironment(b, func(w http.ResponseWriter, r *http.Request) { w.Write(fixture) })
+	client := gooanda.NewClient(b.Name(), gooanda.WithEnvironment(env),
+		gooanda.WithRateLimit(gooanda.RateLimit{}), gooanda.WithoutCompression())
+	var md gooanda.Metadata
+	ctx := gooanda.CaptureMetadata(context.Background(), &md)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(fixture)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Instruments().GetCandlesContext(ctx, "EUR_USD"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeGetTransactions(b *testing.B) {
+	fixture := testTransactionPages(1000)
+	env := newTestEnvironment(b, func(w http.ResponseWriter, r *http.Request) { w.Write(fixture) })
+	client := gooanda.NewClient(b.Name(), gooanda.WithEnvironment(env),
+		gooanda.WithAccountID("101-001-1-001"), gooanda.WithRateLimit(gooanda.RateLimit{}),
+		gooanda.WithoutCompression())
+	b.ReportAllocs()
+	b.SetBytes(int64(len(fixture)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Transactions().GetTransactions(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeGetTransactionsReadAll is the baseline of
+// BenchmarkDecodeGetTransactions.
+func BenchmarkDecodeGetTransactionsReadAll(b *testing.B) {
+	fixture := testTransactionPages(1000)
+	env := newTestEnvironment(b, func(w http.ResponseWriter, r *http.Request) { w.Write(fixture) })
+	client := gooanda.NewClient(b.Name(), gooanda.WithEnvironment(env),
+		gooanda.WithAccountID("101-001-1-001"), gooanda.WithRateLimit(gooanda.RateLimit{}),
+		gooanda.WithoutCompression())
+	var md gooanda.Metadata
+	ctx := gooanda.CaptureMetadata(context.Background(), &md)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(fixture)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Transactions().GetTransactionsContext(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodePricingStream(b *testing.B) {
+	env := newTestEnvironment(b, func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < b.N; i++ {
+			fmt.Fprintln(w, testPrice)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	client := gooanda.NewClient(b.Name(), gooanda.WithEnvironment(env),
+		gooanda.WithAccountID("101-001-1-001"), gooanda.WithRateLimit(gooanda.RateLimit{}),
+		gooanda.WithStreamRateLimit(gooanda.RateLimit{}), gooanda.WithoutCompression())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b.ReportAllocs()
+	b.ResetTimer()
+	events, errs := client.Pricing().StreamPrices(ctx, []string{"EUR_USD"})
+	for i := 0; i < b.N; i++ {
+		select {
+		case <-events:
+		case err := <-errs:
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodePricingStreamReadBytes is the baseline of
+// BenchmarkDecodePricingStream, every message is read into a new slice and
+// unmarshalled.
+func BenchmarkDecodePricingStreamReadBytes(b *testing.B) {
+	env := newTestEnvironment(b, func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < b.N; i++ {
+			fmt.Fprintln(w, testPrice)
+		}
+	})
+	resp, err := http.Get(env.Stream + "/v3/accounts/101-001-1-001/pricing/stream")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			b.Fatal(err)
+		}
+		var event gooanda.PriceEvent
+		if err := json.Unmarshal(line, &event.Price); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package endpoint
 
 import (
 	"fmt"
+	"strings"
 )
 
 type rest string
@@ -20,6 +21,7 @@ type instrument struct {
 
 type account struct {
 	Accounts, AccountsById, AccountSummary, AccountInstrument rest
+	AccountConfiguration, AccountChanges                      rest
 }
 
 type pricing struct {
@@ -28,7 +30,8 @@ type pricing struct {
 }
 
 type order struct {
-	Orders, PendingOrder, OrderDetails rest
+	Orders, PendingOrder, OrderDetails         rest
+	ReplaceOrder, CancelOrder, UpdateClientExt rest
 }
 
 type trade struct {
@@ -59,10 +62,12 @@ var (
 
 func init() {
 	Account = &account{
-		Accounts:          "/v3/accounts",
-		AccountsById:      "/v3/accounts/%v",
-		AccountSummary:    "/v3/accounts/%v/summary",
-		AccountInstrument: "/v3/accounts/%v/instruments",
+		Accounts:             "/v3/accounts",
+		AccountsById:         "/v3/accounts/%v",
+		AccountSummary:       "/v3/accounts/%v/summary",
+		AccountInstrument:    "/v3/accounts/%v/instruments",
+		AccountConfiguration: "/v3/accounts/%v/configuration",
+		AccountChanges:       "/v3/accounts/%v/changes",
 	}
 	Instrument = &instrument{
 		InstrumentCandles:      "/v3/instruments/%v/candles",
@@ -76,9 +81,12 @@ func init() {
 		InstrumentCandles: "/v3/accounts/%v/instruments/%v/candles",
 	}
 	Order = &order{
-		Orders:       "/v3/accounts/%v/orders",
-		PendingOrder: "/v3/accounts/%v/pendingOrders",
-		OrderDetails: "/v3/accounts/%v/orders/%v",
+		Orders:          "/v3/accounts/%v/orders",
+		PendingOrder:    "/v3/accounts/%v/pendingOrders",
+		OrderDetails:    "/v3/accounts/%v/orders/%v",
+		ReplaceOrder:    "/v3/accounts/%v/orders/%v",
+		CancelOrder:     "/v3/accounts/%v/orders/%v/cancel",
+		UpdateClientExt: "/v3/accounts/%v/orders/%v/clientExtensions",
 	}
 	Trade = &trade{
 		Trades:          "/v3/accounts/%v/trades",
@@ -103,22 +111,56 @@ func init() {
 	}
 }
 
-// GetEndpoint is to get the streaming or rest api endpoint.
-// and also for live or practice host.
-func GetEndpoint(isLive bool, endpoint interface{}) string {
-	var host, url string
-	if isLive {
-		host = liveHost
-	} else if !isLive {
-		host = practiceHost
+// Environment is the set of base urls used to build the REST
+// and streaming endpoints.
+type Environment struct {
+	Name   string
+	Rest   string
+	Stream string
+}
+
+var (
+	// Live is the fxTrade environment.
+	Live = Environment{
+		Name:   "live",
+		Rest:   fmt.Sprintf(restApi, liveHost),
+		Stream: fmt.Sprintf(streamApi, liveHost),
+	}
+	// Practice is the fxTrade Practice environment.
+	Practice = Environment{
+		Name:   "practice",
+		Rest:   fmt.Sprintf(restApi, practiceHost),
+		Stream: fmt.Sprintf(streamApi, practiceHost),
+	}
+)
+
+// Custom is to create an environment with explicit REST and streaming
+// base urls, e.g. a local test server or a recording proxy.
+func Custom(rest, stream string) Environment {
+	return Environment{
+		Name:   "custom",
+		Rest:   strings.TrimRight(rest, "/"),
+		Stream: strings.TrimRight(stream, "/"),
 	}
+}
+
+// GetEndpoint is to get the streaming or rest api endpoint
+// of the environment.
+func (env Environment) GetEndpoint(endpoint interface{}) string {
 	switch t := endpoint.(type) {
 	case rest:
-		url = fmt.Sprintf(restApi, host)
-		return url + string(t)
+		return env.Rest + string(t)
 	case stream:
-		url = fmt.Sprintf(streamApi, host)
-		return url + string(t)
+		return env.Stream + string(t)
 	}
 	return ""
 }
+
+// GetEndpoint is to get the streaming or rest api endpoint.
+// and also for live or practice host.
+func GetEndpoint(isLive bool, endpoint interface{}) string {
+	if isLive {
+		return Live.GetEndpoint(endpoint)
+	}
+	return Practice.GetEndpoint(endpoint)
+}
@@ -11,3 +11,16 @@ func TestEndpoint(t *testing.T) {
 	fmt.Println(endpoint.Account.Accounts)
 	fmt.Println(endpoint.GetEndpoint(true, endpoint.Account.Accounts))
 }
+
+func TestCustomEnvironment(t *testing.T) {
+	env := endpoint.Custom("http://127.0.0.1:8080/", "http://127.0.0.1:8081")
+	if got, want := env.GetEndpoint(endpoint.Account.Accounts), "http://127.0.0.1:8080/v3/accounts"; got != want {
+		t.Errorf("rest endpoint = %v, want %v", got, want)
+	}
+	if got, want := env.GetEndpoint(endpoint.Pricing.PricingStream), "http://127.0.0.1:8081/v3/accounts/%v/pricing/stream"; got != want {
+		t.Errorf("stream endpoint = %v, want %v", got, want)
+	}
+	if got, want := endpoint.GetEndpoint(false, endpoint.Account.Accounts), endpoint.Practice.GetEndpoint(endpoint.Account.Accounts); got != want {
+		t.Errorf("practice endpoint = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package gooanda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIError is returned for every response with a non 2xx status code,
+// use errors.As to inspect the details sent by OANDA.
+type APIError struct {
+	StatusCode            int             `json:"-"`
+	RequestID             string          `json:"-"`
+	ErrorCode             string          `json:"errorCode"`
+	ErrorMessage          string          `json:"errorMessage"`
+	RejectReason          string          `json:"rejectReason"`
+	RejectTransaction     json.RawMessage `json:"-"`
+	LastTransactionID     string          `json:"lastTransactionID"`
+	RelatedTransactionIDs []string        `json:"relatedTransactionIDs"`
+	Body                  []byte          `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "oanda api error, status %d %v", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.ErrorCode != "" {
+		fmt.Fprintf(&b, ", errorCode %v", e.ErrorCode)
+	}
+	if e.ErrorMessage != "" {
+		fmt.Fprintf(&b, ", errorMessage %v", e.ErrorMessage)
+	}
+	if e.RejectReason != "" {
+		fmt.Fprintf(&b, ", rejectReason %v", e.RejectReason)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, ", requestID %v", e.RequestID)
+	}
+	return b.String()
+}
+
+// Reject is the reject transaction of the error decoded into its concrete
+// transaction, e.g. *OrderCancelRejectTransaction, it is nil when the
+// request was not rejected by a transaction.
+func (e *APIError) Reject() (Transaction, error) {
+	return decodeOptionalTransaction(e.RejectTransaction)
+}
+
+// newAPIError is to create the api error from the response, the body
+// is read but not closed.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("RequestID"),
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+	apiErr.Body = body
+	if err = json.Unmarshal(body, apiErr); err != nil {
+		return apiErr
+	}
+	// the reject transaction is sent with a name depending on the
+	// endpoint, e.g. orderRejectTransaction or longOrderRejectTransaction,
+	// the first name in order is used when a body has several.
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(body, &fields); err != nil {
+		return apiErr
+	}
+	var names []string
+	for k := range fields {
+		if strings.HasSuffix(k, "RejectTransaction") {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		v := fields[k]
+		apiErr.RejectTransaction = v
+		if apiErr.RejectReason == "" {
+			var reject struct {
+				RejectReason string `json:"rejectReason"`
+			}
+			if json.Unmarshal(v, &reject) == nil {
+				apiErr.RejectReason = reject.RejectReason
+			}
+		}
+		break
+	}
+	return apiErr
+}
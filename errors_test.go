@@ -0,0 +1,75 @@
+package gooanda_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+func TestAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RequestID", "42")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{
+			"orderRejectTransaction": {"id": "6", "type": "MARKET_ORDER_REJECT", "rejectReason": "INSUFFICIENT_MARGIN"},
+			"relatedTransactionIDs": ["6"],
+			"lastTransactionID": "6",
+			"errorCode": "INSUFFICIENT_MARGIN",
+			"errorMessage": "Insufficient margin to perform the operation"
+		}`))
+	})
+	_, err := client.Orders().MarketOrderRequest("EUR_USD", dec("1000000"))
+	var apiErr *gooanda.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.RequestID != "42" {
+		t.Errorf("status = %v, requestID = %v", apiErr.StatusCode, apiErr.RequestID)
+	}
+	if apiErr.ErrorCode != "INSUFFICIENT_MARGIN" || apiErr.RejectReason != "INSUFFICIENT_MARGIN" {
+		t.Errorf("errorCode = %v, rejectReason = %v", apiErr.ErrorCode, apiErr.RejectReason)
+	}
+	if len(apiErr.RejectTransaction) == 0 || apiErr.LastTransactionID != "6" {
+		t.Errorf("rejectTransaction = %s, lastTransactionID = %v",
+			apiErr.RejectTransaction, apiErr.LastTransactionID)
+	}
+}
+
+func TestAPIErrorNotFound(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errorMessage":"The Account specified does not exist"}`))
+	})
+	_, err := client.Accounts().GetAccountSummary()
+	var apiErr *gooanda.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("err = %v, want 404 *APIError", err)
+	}
+	if apiErr.ErrorMessage != "The Account specified does not exist" {
+		t.Errorf("errorMessage = %v", apiErr.ErrorMessage)
+	}
+}
+
+func TestAPIErrorSeveralRejects(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{
+			"shortOrderRejectTransaction": {"id": "7", "type": "MARKET_ORDER_REJECT", "rejectReason": "CLOSEOUT_POSITION_DOESNT_EXIST"},
+			"longOrderRejectTransaction": {"id": "6", "type": "MARKET_ORDER_REJECT", "rejectReason": "INSUFFICIENT_MARGIN"},
+			"lastTransactionID": "7"
+		}`))
+	})
+	// the map of the fields is ranged in a random order.
+	for i := 0; i < 20; i++ {
+		_, err := client.Orders().MarketOrderRequest("EUR_USD", dec("100"))
+		var apiErr *gooanda.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("err = %v, want *APIError", err)
+		}
+		if apiErr.RejectReason != "INSUFFICIENT_MARGIN" {
+			t.Fatalf("rejectReason = %v, want the reason of longOrderRejectTransaction", apiErr.RejectReason)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package gooanda
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,131 +10,126 @@ import (
 	"github.com/kokweikhong/gooanda/endpoint"
 )
 
-// InstrumentCandles data structure
+// InstrumentCandles is the candlesticks of an instrument.
 type InstrumentCandles struct {
-	Candles []struct {
-		Ask      struct{ instrumentOHLC } `json:"ask"`
-		Bid      struct{ instrumentOHLC } `json:"bid"`
-		Mid      struct{ instrumentOHLC } `json:"mid"`
-		Complete bool                     `json:"complete"`
-		Time     time.Time                `json:"time"`
-		Volume   float64                  `json:"volume"`
-	} `json:"candles"`
-	Granularity string `json:"granularity"`
-	Instrument  string `json:"instrument"`
+	Candles     []Candlestick `json:"candles"`
+	Granularity string        `json:"granularity"`
+	Instrument  string        `json:"instrument"`
+}
+
+// Candlestick is the price range of an instrument over the time of a
+// granularity, only the price components requested are set.
+type Candlestick struct {
+	Ask      CandlestickData `json:"ask"`
+	Bid      CandlestickData `json:"bid"`
+	Mid      CandlestickData `json:"mid"`
+	Complete bool            `json:"complete"`
+	Time     Time            `json:"time"`
+	Volume   float64         `json:"volume"`
+}
+
+// CandlestickData is the open, high, low and close prices of a Candlestick.
+type CandlestickData struct {
+	Close Decimal `json:"c"`
+	High  Decimal `json:"h"`
+	Low   Decimal `json:"l"`
+	Open  Decimal `json:"o"`
 }
 
 // InstrumentOrderBook data structure
 type InstrumentOrderBook struct {
-	OrderBook instrumentBook `json:"orderBook"`
+	OrderBook InstrumentBook `json:"orderBook"`
 }
 
 // InstrumentPositionBook data structure.
 type InstrumentPositionBook struct {
-	PositionBook instrumentBook `json:"positionBook"`
+	PositionBook InstrumentBook `json:"positionBook"`
 }
 
-type instrumentBook struct {
-	Instrument  string    `json:"instrument"`
-	Time        time.Time `json:"time"`
-	Price       float64   `json:"price,string"`
-	BucketWidth string    `json:"bucketWidth"`
-	Buckets     []struct {
-		Price             float64 `json:"price,string"`
-		LongCountPercent  string  `json:"longCountPercent"`
-		ShortCountPercent string  `json:"shortCountPercent"`
-	} `json:"buckets"`
+// InstrumentBook is the order book or the position book of an instrument.
+type InstrumentBook struct {
+	Instrument  string                 `json:"instrument"`
+	Time        Time                   `json:"time"`
+	Price       Decimal                `json:"price"`
+	BucketWidth string                 `json:"bucketWidth"`
+	Buckets     []InstrumentBookBucket `json:"buckets"`
 }
 
-type instrumentOHLC struct {
-	Close float64 `json:"c,string"`
-	High  float64 `json:"h,string"`
-	Low   float64 `json:"l,string"`
-	Open  float64 `json:"o,string"`
+// InstrumentBookBucket is the percentage of orders or positions within
+// the bucket width of Price.
+type InstrumentBookBucket struct {
+	Price             Decimal `json:"price"`
+	LongCountPercent  string  `json:"longCountPercent"`
+	ShortCountPercent string  `json:"shortCountPercent"`
 }
 
-type instrument struct {
+// InstrumentService is the INSTRUMENT API of Client.Instruments.
+type InstrumentService struct {
 	connection
 	Query *instrumentFunc
 }
 
-// NewInstrumentConnection create new connection for INSTRUMENT API.
-func NewInstrumentConnection(token string) *instrument {
-	conn := &instrument{}
-	conn.token = token
-	return conn
-}
-
-func (in *instrument) connect() ([]byte, error) {
-	con := &connection{in.endpoint, in.method, in.token, in.data}
-	resp, err := con.connect()
-	if err != nil {
-		return nil, err
-	}
-	return resp, nil
+// GetInstrumentCandles is to fetch candlestick data for an instrument.
+func (in *InstrumentService) GetCandles(instrument string, querys ...InstrumentOpts) (*InstrumentCandles, error) {
+	return in.GetCandlesContext(context.Background(), instrument, querys...)
 }
 
-// GetInstrumentCandles is to fetch candlestick data for an instrument.
-func (in *instrument) GetCandles(live bool, instrument string, querys ...instrumentOpts) (*InstrumentCandles, error) { // {{{
+// GetCandlesContext is GetCandles with a context to cancel the request
+// or to set its deadline.
+func (in *InstrumentService) GetCandlesContext(ctx context.Context, instrument string, querys ...InstrumentOpts) (*InstrumentCandles, error) { // {{{
 	q := newInstrumentQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Instrument.InstrumentCandles)
+	ep := in.getEndpoint(endpoint.Instrument.InstrumentCandles)
 	url := fmt.Sprintf(ep, instrument)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	in.endpoint = u
-	in.method = http.MethodGet
-	resp, err := in.connect()
-	if err != nil {
-		return nil, err
-	}
 	var data = &InstrumentCandles{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	if err = in.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
 } // }}}
 
 // GetInstrumentOrderBook is to fetch an order book for an instrument.
-func (in *instrument) GetOrderBook(live bool, instrument string, querys ...instrumentOpts) (*InstrumentOrderBook, error) { // {{{
+func (in *InstrumentService) GetOrderBook(instrument string, querys ...InstrumentOpts) (*InstrumentOrderBook, error) {
+	return in.GetOrderBookContext(context.Background(), instrument, querys...)
+}
+
+// GetOrderBookContext is GetOrderBook with a context to cancel the request
+// or to set its deadline.
+func (in *InstrumentService) GetOrderBookContext(ctx context.Context, instrument string, querys ...InstrumentOpts) (*InstrumentOrderBook, error) { // {{{
 	q := newInstrumentQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Instrument.InstrumentOrderBook)
+	ep := in.getEndpoint(endpoint.Instrument.InstrumentOrderBook)
 	url := fmt.Sprintf(ep, instrument)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	in.endpoint = u
-	in.method = http.MethodGet
-	resp, err := in.connect()
-	if err != nil {
-		return nil, err
-	}
 	var data = &InstrumentOrderBook{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	if err = in.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
 } // }}}
 
 // GetInstrumentPositionBook is to fetch a position book for an instrument.
-func (in *instrument) GetPositionBook(live bool, instrument string, querys ...instrumentOpts) (*InstrumentPositionBook, error) { // {{{
+func (in *InstrumentService) GetPositionBook(instrument string, querys ...InstrumentOpts) (*InstrumentPositionBook, error) {
+	return in.GetPositionBookContext(context.Background(), instrument, querys...)
+}
+
+// GetPositionBookContext is GetPositionBook with a context to cancel the request
+// or to set its deadline.
+func (in *InstrumentService) GetPositionBookContext(ctx context.Context, instrument string, querys ...InstrumentOpts) (*InstrumentPositionBook, error) { // {{{
 	q := newInstrumentQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Instrument.InstrumentPositionBook)
+	ep := in.getEndpoint(endpoint.Instrument.InstrumentPositionBook)
 	url := fmt.Sprintf(ep, instrument)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	in.endpoint = u
-	in.method = http.MethodGet
-	resp, err := in.connect()
-	if err != nil {
-		return nil, err
-	}
 	var data = &InstrumentPositionBook{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	if err = in.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
@@ -154,9 +149,10 @@ type instrumentQuery struct {
 	Time              string `json:"time,omitempty"`
 }
 
-type instrumentOpts func(*instrumentQuery)
+// InstrumentOpts is an option of the instrument requests.
+type InstrumentOpts func(*instrumentQuery)
 
-func newInstrumentQuery(querys ...instrumentOpts) *instrumentQuery {
+func newInstrumentQuery(querys ...InstrumentOpts) *instrumentQuery {
 	q := &instrumentQuery{}
 	for _, query := range querys {
 		query(q)
@@ -168,7 +164,7 @@ type instrumentFunc struct{}
 
 // WithTime is the time of the snapshot to fetch. If not specified
 // then the most recent snapshot is fetched.
-func (*instrumentFunc) WithTime(setTime time.Time) instrumentOpts {
+func (*instrumentFunc) WithTime(setTime time.Time) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		if setTime.Unix() > time.Now().Unix() {
 			iq.Time = ""
@@ -179,7 +175,7 @@ func (*instrumentFunc) WithTime(setTime time.Time) instrumentOpts {
 
 // WithWeeklyAlignment is the day of the week used for granularities
 // that have weekly alignment. [default=Friday]
-func (*instrumentFunc) WithWeeklyAlignment(weeklyAlignment string) instrumentOpts {
+func (*instrumentFunc) WithWeeklyAlignment(weeklyAlignment string) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		iq.WeeklyAlignment = weeklyAlignment
 	}
@@ -189,7 +185,7 @@ func (*instrumentFunc) WithWeeklyAlignment(weeklyAlignment string) instrumentOpt
 // Candlesticks with daily alignment will be aligned to the dailyAlignment hour
 // within the alignmentTimezone. Note that the returned times will still
 // be represented in UTC. [default=America/New_York]
-func (*instrumentFunc) WithAlignmentTimezone(timezone string) instrumentOpts {
+func (*instrumentFunc) WithAlignmentTimezone(timezone string) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		iq.AlignmentTimezone = timezone
 	}
@@ -198,7 +194,7 @@ func (*instrumentFunc) WithAlignmentTimezone(timezone string) instrumentOpts {
 // WithDailyAlignment is the hour of the day (in the specified timezone)
 // to use for granularities that have daily alignments.
 // [default=17, minimum=0, maximum=23]
-func (*instrumentFunc) WithDailyAlignment(alignment int) instrumentOpts {
+func (*instrumentFunc) WithDailyAlignment(alignment int) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		if alignment > 23 || alignment < 0 {
 			iq.DailyAlignment = "17"
@@ -213,7 +209,7 @@ func (*instrumentFunc) WithDailyAlignment(alignment int) instrumentOpts {
 // This flag enables clients to use the timestamp of the last completed
 // candlestick received to poll for future candlesticks but avoid receiving
 // the previous candlestick repeatedly. [default=True]
-func (*instrumentFunc) WithWithoutIncludeFirst() instrumentOpts {
+func (*instrumentFunc) WithWithoutIncludeFirst() InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		iq.IncludeFirst = "false"
 	}
@@ -223,14 +219,14 @@ func (*instrumentFunc) WithWithoutIncludeFirst() instrumentOpts {
 // A smoothed candlestick uses the previous candle’s close price as its
 // open price, while an un-smoothed candlestick uses the first price from
 // its time range as its open price. [default=False]
-func (*instrumentFunc) WithSmooth() instrumentOpts {
+func (*instrumentFunc) WithSmooth() InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		iq.Smooth = true
 	}
 }
 
 // WithFrom is the start of the time range to fetch candlesticks for.
-func (*instrumentFunc) WithFrom(from time.Time) instrumentOpts {
+func (*instrumentFunc) WithFrom(from time.Time) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		if from.Unix() > time.Now().Unix() {
 			iq.From = ""
@@ -241,7 +237,7 @@ func (*instrumentFunc) WithFrom(from time.Time) instrumentOpts {
 }
 
 // WithTo is the end of the time range to fetch candlesticks for.
-func (*instrumentFunc) WithTo(to time.Time) instrumentOpts {
+func (*instrumentFunc) WithTo(to time.Time) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		if to.Unix() > time.Now().Unix() {
 			iq.To = ""
@@ -253,7 +249,7 @@ func (*instrumentFunc) WithTo(to time.Time) instrumentOpts {
 
 // WithFromTo is the start of the time range to fetch candlesticks for
 // and the end of the time range to fetch candlesticks for.
-func (*instrumentFunc) WithFromTo(from, to time.Time) instrumentOpts {
+func (*instrumentFunc) WithFromTo(from, to time.Time) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		if to.Unix() < from.Unix() || to.Unix() > time.Now().Unix() {
 			iq.From = ""
@@ -261,7 +257,6 @@ func (*instrumentFunc) WithFromTo(from, to time.Time) instrumentOpts {
 			return
 		}
 		iq.From = from.Format(time.RFC3339)
-		fmt.Println(iq.From)
 		iq.To = to.Format(time.RFC3339)
 	}
 }
@@ -270,7 +265,7 @@ func (*instrumentFunc) WithFromTo(from, to time.Time) instrumentOpts {
 // Count should not be specified if both the start and end parameters
 // are provided, as the time range combined with the granularity will
 // determine the number of candlesticks to return. [default=500, maximum=5000]
-func (*instrumentFunc) WithCount(count int) instrumentOpts {
+func (*instrumentFunc) WithCount(count int) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		if count > 5000 {
 			iq.Count = 5000
@@ -280,14 +275,14 @@ func (*instrumentFunc) WithCount(count int) instrumentOpts {
 }
 
 // WithGranularity is the granularity of the candlesticks to fetch [default=S5]
-func (iq *instrumentFunc) WithGranularity(granularity string) instrumentOpts {
+func (iq *instrumentFunc) WithGranularity(granularity string) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		iq.Granularity = string(granularity)
 	}
 }
 
 // WithPrice is the Price component(s) to get candlestick data for. [default=M]
-func (*instrumentFunc) WithPrice(price string) instrumentOpts {
+func (*instrumentFunc) WithPrice(price string) InstrumentOpts {
 	return func(iq *instrumentQuery) {
 		iq.Price = price
 	}
@@ -0,0 +1,11 @@
+package kw
+
+type datetimeFormat struct {
+	RFC3339 string // DateTime fields are RFC 3339 strings, e.g. "2021-06-01T08:00:00.000000000Z"
+	UNIX    string // DateTime fields are UNIX seconds with fraction, e.g. "1622534400.000000000"
+}
+
+var DATETIMEFORMAT = &datetimeFormat{
+	RFC3339: "RFC3339",
+	UNIX:    "UNIX",
+}
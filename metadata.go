@@ -0,0 +1,83 @@
+package gooanda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metadata is the details of the response of a REST request, it is set for
+// the requests sent with a context returned by CaptureMetadata.
+type Metadata struct {
+	StatusCode int
+	Header     http.Header
+	// RequestID is the id given by OANDA to the request, quote it
+	// when contacting OANDA about the request.
+	RequestID string
+	// Latency is the time from sending the first attempt until the
+	// response headers are received, including the retries.
+	Latency  time.Duration
+	Attempts int
+	// LastTransactionID is the last transaction of the account when the
+	// response was created, empty when the response does not have it.
+	LastTransactionID string
+	RateLimit         RateLimitHeader
+}
+
+// RateLimitHeader is the rate limit headers of a response, a field is
+// zero when its header is not sent.
+type RateLimitHeader struct {
+	Limit      int           // X-RateLimit-Limit
+	Remaining  int           // X-RateLimit-Remaining
+	Reset      time.Time     // X-RateLimit-Reset, in unix seconds
+	RetryAfter time.Duration // Retry-After
+}
+
+type metadataKey struct{}
+
+// CaptureMetadata is to get a context which sets md with the metadata of
+// the response of the requests sent with it. Use a context per request,
+// md is overwritten by every request and is not set for streams.
+func CaptureMetadata(ctx context.Context, md *Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+func metadataFrom(ctx context.Context) *Metadata {
+	md, _ := ctx.Value(metadataKey{}).(*Metadata)
+	return md
+}
+
+// setResponse is to set the metadata from the response headers.
+func (md *Metadata) setResponse(resp *http.Response, start time.Time, attempts int) {
+	*md = Metadata{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		RequestID:  resp.Header.Get("RequestID"),
+		Latency:    time.Since(start),
+		Attempts:   attempts,
+		RateLimit:  parseRateLimitHeader(resp.Header),
+	}
+}
+
+// setBody is to set the metadata from the response body.
+func (md *Metadata) setBody(body []byte) {
+	var result struct {
+		LastTransactionID string `json:"lastTransactionID"`
+	}
+	if json.Unmarshal(body, &result) == nil {
+		md.LastTransactionID = result.LastTransactionID
+	}
+}
+
+func parseRateLimitHeader(h http.Header) RateLimitHeader {
+	var rl RateLimitHeader
+	rl.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	rl.Remaining, _ = strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+	}
+	rl.RetryAfter, _ = retryAfter(h.Get("Retry-After"))
+	return rl
+}
@@ -0,0 +1,54 @@
+package gooanda_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+func TestCaptureMetadata(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("RequestID", fmt.Sprint(calls))
+		w.Header().Set("X-RateLimit-Remaining", "119")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"account":{"id":"101-001-1-001"},"lastTransactionID":"1234"}`)
+	}, gooanda.WithRetryPolicy(testRetryPolicy))
+	var md gooanda.Metadata
+	ctx := gooanda.CaptureMetadata(context.Background(), &md)
+	if _, err := client.Accounts().GetAccountSummaryContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if md.StatusCode != http.StatusOK || md.RequestID != "2" || md.Attempts != 2 {
+		t.Errorf("status = %v, requestID = %v, attempts = %v", md.StatusCode, md.RequestID, md.Attempts)
+	}
+	if md.LastTransactionID != "1234" || md.RateLimit.Remaining != 119 || md.Latency <= 0 {
+		t.Errorf("metadata = %+v", md)
+	}
+}
+
+func TestCaptureMetadataAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RequestID", "42")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errorMessage":"bad request","lastTransactionID":"7"}`)
+	})
+	var md gooanda.Metadata
+	ctx := gooanda.CaptureMetadata(context.Background(), &md)
+	_, err := client.Orders().MarketOrderRequestContext(ctx, "EUR_USD", dec("100"))
+	var apiErr *gooanda.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if md.StatusCode != http.StatusBadRequest || md.RequestID != "42" || md.LastTransactionID != "7" {
+		t.Errorf("metadata = %+v", md)
+	}
+}
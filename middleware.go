@@ -0,0 +1,88 @@
+package gooanda
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// RoundTripFunc is to send a single request and get its response.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the sending of every REST and stream request, it can
+// observe and modify the request before calling next and the response after.
+// The request carries the access token in its Authorization header, use
+// RedactHeader before logging the header.
+// Every attempt of a retried request goes through the middleware.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware is to add middleware around every request, the first
+// middleware is the outermost one.
+func WithMiddleware(middleware ...Middleware) ClientOpts {
+	return func(c *Client) { c.middleware = append(c.middleware, middleware...) }
+}
+
+// wrapMiddleware is to wrap roundTrip with the middleware of the client,
+// the middleware only sees errors with the access token redacted.
+func (c *Client) wrapMiddleware(next RoundTripFunc) RoundTripFunc {
+	roundTrip := func(req *http.Request) (*http.Response, error) {
+		resp, err := next(req)
+		return resp, c.redact(err)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		roundTrip = c.middleware[i](roundTrip)
+	}
+	return roundTrip
+}
+
+// TimingEvent is the timing of a single request, for a stream the
+// duration is the time until the response headers are received.
+type TimingEvent struct {
+	Method     string
+	Path       string
+	Stream     bool
+	StatusCode int
+	RequestID  string
+	Duration   time.Duration
+	Err        error
+}
+
+// TimingMiddleware is to call observe with the timing of every request.
+func TimingMiddleware(observe func(TimingEvent)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			event := TimingEvent{
+				Method:   req.Method,
+				Path:     req.URL.Path,
+				Stream:   isStream(req.URL),
+				Duration: time.Since(start),
+				Err:      err,
+			}
+			if resp != nil {
+				event.StatusCode = resp.StatusCode
+				event.RequestID = resp.Header.Get("RequestID")
+			}
+			observe(event)
+			return resp, err
+		}
+	}
+}
+
+// LoggingMiddleware is to log every request as key=value pairs,
+// the log.Default logger is used when logger is nil.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return TimingMiddleware(func(e TimingEvent) {
+		if e.Err != nil {
+			logger.Printf("method=%v path=%v stream=%v duration=%v error=%q",
+				e.Method, e.Path, e.Stream, e.Duration, e.Err)
+			return
+		}
+		logger.Printf("method=%v path=%v stream=%v status=%d requestID=%v duration=%v",
+			e.Method, e.Path, e.Stream, e.StatusCode, e.RequestID, e.Duration)
+	})
+}
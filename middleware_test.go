@@ -0,0 +1,98 @@
+package gooanda_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) gooanda.Middleware {
+		return func(next gooanda.RoundTripFunc) gooanda.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+">")
+				resp, err := next(req)
+				order = append(order, "<"+name)
+				return resp, err
+			}
+		}
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"accounts":[]}`)
+	}, gooanda.WithMiddleware(mark("a"), mark("b")))
+	if _, err := client.Accounts().GetAccountList(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(order, " "); got != "a> b> <b <a" {
+		t.Errorf("order = %v", got)
+	}
+}
+
+func TestMiddlewareModify(t *testing.T) {
+	// rotate the token and inject a failure for the instrument EUR_JPY.
+	middleware := func(next gooanda.RoundTripFunc) gooanda.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer rotated")
+			if strings.Contains(req.URL.Path, "EUR_JPY") {
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Header:     http.Header{},
+					Body:       ioutil.NopCloser(strings.NewReader(`{"errorMessage":"injected"}`)),
+					Request:    req,
+				}, nil
+			}
+			return next(req)
+		}
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer rotated" {
+			t.Errorf("authorization = %v", got)
+		}
+		fmt.Fprint(w, `{"candles":[]}`)
+	}, gooanda.WithMiddleware(middleware), gooanda.WithoutRetry())
+	if _, err := client.Instruments().GetCandles("EUR_USD"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.Instruments().GetCandles("EUR_JPY")
+	var apiErr *gooanda.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorMessage != "injected" {
+		t.Errorf("err = %v, want injected *APIError", err)
+	}
+}
+
+func TestLoggingAndTimingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	var events []gooanda.TimingEvent
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RequestID", "42")
+		fmt.Fprint(w, `{"accounts":[]}`)
+	}, gooanda.WithMiddleware(
+		gooanda.LoggingMiddleware(log.New(&buf, "", 0)),
+		gooanda.TimingMiddleware(func(e gooanda.TimingEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		})))
+	if _, err := client.Accounts().GetAccountList(); err != nil {
+		t.Fatal(err)
+	}
+	line := buf.String()
+	for _, want := range []string{"method=GET", "path=/v3/accounts", "status=200", "requestID=42", "duration="} {
+		if !strings.Contains(line, want) {
+			t.Errorf("log %q does not contain %v", line, want)
+		}
+	}
+	if len(events) != 1 || events[0].StatusCode != http.StatusOK || events[0].Duration <= 0 {
+		t.Errorf("timing events = %+v", events)
+	}
+}
@@ -1,9 +1,9 @@
 package gooanda
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,26 +12,359 @@ import (
 	"github.com/kokweikhong/gooanda/kw"
 )
 
-type order struct {
-	connection
-	Config *orderConfigFunc
-	Query  *orderQueryFunc
+// Order is an order of an Account, use a type switch to get the concrete
+// order, e.g. *LimitOrder or *StopLossOrder.
+type Order interface {
+	Base() *BaseOrder
 }
 
-// NewOrderConnection is create connection for ORDER API.
-func NewOrderConnection(token string) *order {
-	conn := &order{}
-	conn.token = token
-	return conn
+// BaseOrder is the fields common to every order type.
+type BaseOrder struct { // {{{
+	ID                      string            `json:"id"`
+	CreateTime              Time              `json:"createTime"`
+	State                   string            `json:"state"`
+	ClientExtensions        *ClientExtensions `json:"clientExtensions,omitempty"`
+	Type                    string            `json:"type"`
+	FillingTransactionID    string            `json:"fillingTransactionID,omitempty"`
+	FilledTime              Time              `json:"filledTime"`
+	TradeOpenedID           string            `json:"tradeOpenedID,omitempty"`
+	TradeReducedID          string            `json:"tradeReducedID,omitempty"`
+	TradeClosedIDs          []string          `json:"tradeClosedIDs,omitempty"`
+	CancellingTransactionID string            `json:"cancellingTransactionID,omitempty"`
+	CancelledTime           Time              `json:"cancelledTime"`
+	ReplacesOrderID         string            `json:"replacesOrderID,omitempty"`
+	ReplacedByOrderID       string            `json:"replacedByOrderID,omitempty"`
+} // }}}
+
+// Base is the fields common to every order type.
+func (o *BaseOrder) Base() *BaseOrder { return o }
+
+// ClientExtensions is the client data attached to an order or a trade,
+// it must not be set for an account used by MT4.
+type ClientExtensions struct {
+	ID      string `json:"id,omitempty"`
+	Tag     string `json:"tag,omitempty"`
+	Comment string `json:"comment,omitempty"`
 }
 
-func (od *order) connect() ([]byte, error) {
-	con := &connection{od.endpoint, od.method, od.token, od.data}
-	resp, err := con.connect()
+// onFill is the dependent orders created when an entry order is filled.
+type onFill struct {
+	TakeProfitOnFill         *TakeProfitDetails         `json:"takeProfitOnFill,omitempty"`
+	StopLossOnFill           *StopLossDetails           `json:"stopLossOnFill,omitempty"`
+	TrailingStopLossOnFill   *TrailingStopLossDetails   `json:"trailingStopLossOnFill,omitempty"`
+	GuaranteedStopLossOnFill *GuaranteedStopLossDetails `json:"guaranteedStopLossOnFill,omitempty"`
+	TradeClientExtensions    *ClientExtensions          `json:"tradeClientExtensions,omitempty"`
+}
+
+// MarketOrder is an order filled immediately at the current market price.
+type MarketOrder struct { // {{{
+	BaseOrder
+	onFill
+	Instrument            string                       `json:"instrument"`
+	Units                 Decimal                      `json:"units"`
+	TimeInForce           string                       `json:"timeInForce"`
+	PriceBound            Decimal                      `json:"priceBound"`
+	PositionFill          string                       `json:"positionFill"`
+	TradeClose            *MarketOrderTradeClose       `json:"tradeClose,omitempty"`
+	LongPositionCloseout  *MarketOrderPositionCloseout `json:"longPositionCloseout,omitempty"`
+	ShortPositionCloseout *MarketOrderPositionCloseout `json:"shortPositionCloseout,omitempty"`
+	MarginCloseout        *MarketOrderMarginCloseout   `json:"marginCloseout,omitempty"`
+} // }}}
+
+// MarketOrderTradeClose is the trade closed by a MarketOrder.
+type MarketOrderTradeClose struct {
+	TradeID       string `json:"tradeID"`
+	ClientTradeID string `json:"clientTradeID,omitempty"`
+	Units         string `json:"units"`
+}
+
+// MarketOrderPositionCloseout is the position closed by a MarketOrder,
+// Units is a number of units or ALL.
+type MarketOrderPositionCloseout struct {
+	Instrument string `json:"instrument"`
+	Units      string `json:"units"`
+}
+
+// MarketOrderMarginCloseout is the reason of a MarketOrder created
+// for a margin closeout.
+type MarketOrderMarginCloseout struct {
+	Reason string `json:"reason"`
+}
+
+// FixedPriceOrder is an order filled immediately at a fixed price.
+type FixedPriceOrder struct {
+	BaseOrder
+	onFill
+	Instrument   string  `json:"instrument"`
+	Units        Decimal `json:"units"`
+	Price        Decimal `json:"price"`
+	PositionFill string  `json:"positionFill"`
+	TradeState   string  `json:"tradeState"`
+}
+
+// entryOrder is the fields of the orders filled when the price
+// reaches the price of the order.
+type entryOrder struct {
+	onFill
+	Instrument       string  `json:"instrument"`
+	Units            Decimal `json:"units"`
+	Price            Decimal `json:"price"`
+	TimeInForce      string  `json:"timeInForce"`
+	GtdTime          Time    `json:"gtdTime"`
+	PositionFill     string  `json:"positionFill"`
+	TriggerCondition string  `json:"triggerCondition"`
+}
+
+// LimitOrder is an order filled at the price or a better price.
+type LimitOrder struct {
+	BaseOrder
+	entryOrder
+}
+
+// StopOrder is an order filled at the price or a worse price.
+type StopOrder struct {
+	BaseOrder
+	entryOrder
+	PriceBound Decimal `json:"priceBound"`
+}
+
+// MarketIfTouchedOrder is an order filled when the price is touched,
+// from the market price when the order was created.
+type MarketIfTouchedOrder struct {
+	BaseOrder
+	entryOrder
+	PriceBound         Decimal `json:"priceBound"`
+	InitialMarketPrice Decimal `json:"initialMarketPrice"`
+}
+
+// dependentOrder is the fields of the orders closing a trade.
+type dependentOrder struct {
+	TradeID          string  `json:"tradeID"`
+	ClientTradeID    string  `json:"clientTradeID,omitempty"`
+	Price            Decimal `json:"price"`
+	TimeInForce      string  `json:"timeInForce"`
+	GtdTime          Time    `json:"gtdTime"`
+	TriggerCondition string  `json:"triggerCondition"`
+}
+
+// TakeProfitOrder is an order closing a trade at the price or a better price.
+type TakeProfitOrder struct {
+	BaseOrder
+	dependentOrder
+}
+
+// StopLossOrder is an order closing a trade at the price or a worse price,
+// the price is computed from Distance when it is set.
+type StopLossOrder struct {
+	BaseOrder
+	dependentOrder
+	Distance Decimal `json:"distance"`
+}
+
+// GuaranteedStopLossOrder is an order closing a trade at the price
+// guaranteed whatever the market conditions.
+type GuaranteedStopLossOrder struct {
+	BaseOrder
+	dependentOrder
+	Distance                   Decimal `json:"distance"`
+	GuaranteedExecutionPremium Decimal `json:"guaranteedExecutionPremium"`
+}
+
+// TrailingStopLossOrder is an order closing a trade at Distance behind
+// the best price reached by the trade.
+type TrailingStopLossOrder struct {
+	BaseOrder
+	dependentOrder
+	Distance          Decimal `json:"distance"`
+	TrailingStopValue Decimal `json:"trailingStopValue"`
+}
+
+// newOrder is to create the concrete order of the order type.
+func newOrder(orderType string) Order {
+	switch orderType {
+	case kw.ORDERTYPE.MARKET:
+		return &MarketOrder{}
+	case kw.ORDERTYPE.FIXED_PRICE:
+		return &FixedPriceOrder{}
+	case kw.ORDERTYPE.LIMIT:
+		return &LimitOrder{}
+	case kw.ORDERTYPE.STOP:
+		return &StopOrder{}
+	case kw.ORDERTYPE.MARKET_IF_TOUCHED:
+		return &MarketIfTouchedOrder{}
+	case kw.ORDERTYPE.TAKE_PROFIT:
+		return &TakeProfitOrder{}
+	case kw.ORDERTYPE.STOP_LOSS:
+		return &StopLossOrder{}
+	case kw.ORDERTYPE.GUARANTEED_STOP_LOSS:
+		return &GuaranteedStopLossOrder{}
+	case kw.ORDERTYPE.TRAILING_STOP_LOSS:
+		return &TrailingStopLossOrder{}
+	}
+	return &BaseOrder{}
+}
+
+// decodeOrder is to decode the order into the concrete order of its type,
+// an order of an unknown type is decoded into *BaseOrder.
+func decodeOrder(data []byte) (Order, error) {
+	var base struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order %s, %v", data, err)
+	}
+	o := newOrder(base.Type)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order %s to %T, %v", data, o, err)
+	}
+	return o, nil
+}
+
+// Orders is a list of orders decoded into their concrete order types.
+type Orders []Order
+
+func (os *Orders) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	orders := make(Orders, 0, len(raws))
+	for _, raw := range raws {
+		o, err := decodeOrder(raw)
+		if err != nil {
+			return err
+		}
+		orders = append(orders, o)
+	}
+	*os = orders
+	return nil
+}
+
+// OrderList is the response of GetOrderList and GetPendingOrders.
+type OrderList struct {
+	Orders            Orders `json:"orders"`
+	LastTransactionID string `json:"lastTransactionID"`
+}
+
+// OrderDetails is the response of GetOrderDetails.
+type OrderDetails struct {
+	Order             Order  `json:"order"`
+	LastTransactionID string `json:"lastTransactionID"`
+}
+
+func (od *OrderDetails) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Order             json.RawMessage `json:"order"`
+		LastTransactionID string          `json:"lastTransactionID"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	od.LastTransactionID = raw.LastTransactionID
+	if len(raw.Order) == 0 {
+		return nil
+	}
+	o, err := decodeOrder(raw.Order)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	od.Order = o
+	return nil
+}
+
+// OrderCreateResponse is the response of the order requests, the order is
+// created by OrderCreateTransaction and when it is filled or cancelled
+// immediately the fill or cancel transaction is set.
+type OrderCreateResponse struct { // {{{
+	OrderCreateTransaction        Transaction             `json:"orderCreateTransaction"`
+	OrderFillTransaction          *OrderFillTransaction   `json:"orderFillTransaction,omitempty"`
+	OrderCancelTransaction        *OrderCancelTransaction `json:"orderCancelTransaction,omitempty"`
+	OrderReissueTransaction       Transaction             `json:"orderReissueTransaction,omitempty"`
+	OrderReissueRejectTransaction Transaction             `json:"orderReissueRejectTransaction,omitempty"`
+	RelatedTransactionIDs         []string                `json:"relatedTransactionIDs"`
+	LastTransactionID             string                  `json:"lastTransactionID"`
+} // }}}
+
+func (r *OrderCreateResponse) UnmarshalJSON(data []byte) error {
+	type response OrderCreateResponse
+	var raw struct {
+		*response
+		OrderCreateTransaction        json.RawMessage `json:"orderCreateTransaction"`
+		OrderReissueTransaction       json.RawMessage `json:"orderReissueTransaction"`
+		OrderReissueRejectTransaction json.RawMessage `json:"orderReissueRejectTransaction"`
+	}
+	raw.response = (*response)(r)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var err error
+	if r.OrderCreateTransaction, err = decodeOptionalTransaction(raw.OrderCreateTransaction); err != nil {
+		return err
+	}
+	if r.OrderReissueTransaction, err = decodeOptionalTransaction(raw.OrderReissueTransaction); err != nil {
+		return err
+	}
+	r.OrderReissueRejectTransaction, err = decodeOptionalTransaction(raw.OrderReissueRejectTransaction)
+	return err
+}
+
+// OrderReplaceResponse is the response of ReplaceOrder, the order replaced
+// is cancelled by OrderCancelTransaction and the new order is created by
+// OrderCreateTransaction. When the new order is filled or cancelled
+// immediately the fill or ReplacingOrderCancelTransaction is set.
+type OrderReplaceResponse struct { // {{{
+	OrderCancelTransaction          *OrderCancelTransaction `json:"orderCancelTransaction"`
+	OrderCreateTransaction          Transaction             `json:"orderCreateTransaction"`
+	OrderFillTransaction            *OrderFillTransaction   `json:"orderFillTransaction,omitempty"`
+	OrderReissueTransaction         Transaction             `json:"orderReissueTransaction,omitempty"`
+	OrderReissueRejectTransaction   Transaction             `json:"orderReissueRejectTransaction,omitempty"`
+	ReplacingOrderCancelTransaction *OrderCancelTransaction `json:"replacingOrderCancelTransaction,omitempty"`
+	RelatedTransactionIDs           []string                `json:"relatedTransactionIDs"`
+	LastTransactionID               string                  `json:"lastTransactionID"`
+} // }}}
+
+func (r *OrderReplaceResponse) UnmarshalJSON(data []byte) error {
+	type response OrderReplaceResponse
+	var raw struct {
+		*response
+		OrderCreateTransaction        json.RawMessage `json:"orderCreateTransaction"`
+		OrderReissueTransaction       json.RawMessage `json:"orderReissueTransaction"`
+		OrderReissueRejectTransaction json.RawMessage `json:"orderReissueRejectTransaction"`
+	}
+	raw.response = (*response)(r)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
 	}
-	return resp, nil
+	var err error
+	if r.OrderCreateTransaction, err = decodeOptionalTransaction(raw.OrderCreateTransaction); err != nil {
+		return err
+	}
+	if r.OrderReissueTransaction, err = decodeOptionalTransaction(raw.OrderReissueTransaction); err != nil {
+		return err
+	}
+	r.OrderReissueRejectTransaction, err = decodeOptionalTransaction(raw.OrderReissueRejectTransaction)
+	return err
+}
+
+// OrderCancelResponse is the response of CancelOrder.
+type OrderCancelResponse struct {
+	OrderCancelTransaction *OrderCancelTransaction `json:"orderCancelTransaction"`
+	RelatedTransactionIDs  []string                `json:"relatedTransactionIDs"`
+	LastTransactionID      string                  `json:"lastTransactionID"`
+}
+
+// OrderClientExtensionsResponse is the response of UpdateOrderClientExtensions.
+type OrderClientExtensionsResponse struct {
+	OrderClientExtensionsModifyTransaction *OrderClientExtensionsModifyTransaction `json:"orderClientExtensionsModifyTransaction"`
+	RelatedTransactionIDs                  []string                                `json:"relatedTransactionIDs"`
+	LastTransactionID                      string                                  `json:"lastTransactionID"`
+}
+
+// OrderService is the ORDER API of Client.Orders.
+type OrderService struct {
+	connection
+	Config *orderConfigFunc
+	Query  *orderQueryFunc
 }
 
 // -------------ORDER QUERY SECTION--------------------
@@ -44,9 +377,10 @@ type orderQuery struct {
 	BeforeID   string `json:"beforeID,omitempty"`
 }
 
-type orderOpts func(*orderQuery)
+// OrderOpts is an option of the order queries.
+type OrderOpts func(*orderQuery)
 
-func newOrderQuery(querys ...orderOpts) *orderQuery {
+func newOrderQuery(querys ...OrderOpts) *orderQuery {
 	q := &orderQuery{}
 	for _, query := range querys {
 		query(q)
@@ -57,7 +391,7 @@ func newOrderQuery(querys ...orderOpts) *orderQuery {
 type orderQueryFunc struct{}
 
 // WithCount is the maximum number of Orders to return [default=50, maximum=500]
-func (*orderQueryFunc) WithCount(count int) orderOpts {
+func (*orderQueryFunc) WithCount(count int) OrderOpts {
 	return func(oq *orderQuery) {
 		if count < 0 || count > 5000 {
 			oq.Count = "500"
@@ -69,28 +403,28 @@ func (*orderQueryFunc) WithCount(count int) orderOpts {
 }
 
 // WithState is the state to filter the requested Orders by.
-func (*orderQueryFunc) WithState(orderState string) orderOpts {
+func (*orderQueryFunc) WithState(orderState string) OrderOpts {
 	return func(oq *orderQuery) {
 		oq.State = orderState
 	}
 }
 
 // WithIstrument is the instrument to filter the requested orders by.
-func (*orderQueryFunc) WithInstrument(instrument string) orderOpts {
+func (*orderQueryFunc) WithInstrument(instrument string) OrderOpts {
 	return func(oq *orderQuery) {
 		oq.Instrument = instrument
 	}
 }
 
 // WithListId is List of OrderID (csv), List of Order IDs to retrieve
-func (*orderQueryFunc) WithListID(ids []string) orderOpts {
+func (*orderQueryFunc) WithListID(ids []string) OrderOpts {
 	return func(oq *orderQuery) {
 		oq.Ids = strings.Join(ids, ",")
 	}
 }
 
 // WithID is the maximum Order ID to return. If not provided the most recent Orders in the Account are returned
-func (*orderQueryFunc) WithID(id string) orderOpts {
+func (*orderQueryFunc) WithID(id string) OrderOpts {
 	return func(oq *orderQuery) {
 		oq.BeforeID = id
 	}
@@ -99,50 +433,67 @@ func (*orderQueryFunc) WithID(id string) orderOpts {
 // --------------CREATE ORDER CONFIGURATION SECTION-----------------
 // {{{
 type configOrder struct {
-	Order struct {
-		Type                     string      `json:"type"`
-		Instrument               string      `json:"instrument,omitempty"`
-		Units                    float64     `json:"units,string,omitempty"`
-		TimeInForce              string      `json:"timeInForce"`
-		Price                    float64     `json:"price,omitempty,string"`
-		PriceBound               float64     `json:"priceBound,omitempty,string"`
-		PositionFill             string      `json:"positionFill,omitempty"`
-		TakeProfitOnFill         *detailSLTP `json:"takeProfitOnFill,omitempty"`
-		StopLossOnFill           *detailSLTP `json:"stopLossOnFill,omitempty"`
-		TriggerCondition         string      `json:"triggerCondition,omitempty"`
-		TradeID                  string      `json:"tradeID,omitempty,string"`
-		ClientTradeID            string      `json:"clientTradeID,omitempty,string"`
-		Distance                 float64     `json:"distance,omitempty,string"`
-		TrailingStopLossOnFill   *tSLOF      `json:"trailingStopLossOnFill,omitempty"`
-		GuaranteedStopLossOnFill *gSLOF      `json:"guaranteedStopLossOnFill,omitempty"`
-	} `json:"order"`
-}
-
-// TrailingStopLossOnFill
-type tSLOF struct {
-	Distance    float64 `json:"distance,omitempty,string"`
-	GtdTime     string  `json:"gtdTime,omitempty"`
-	TimeInForce string  `json:"timeInForce"`
+	Order OrderRequest `json:"order"`
 }
 
-// GuaranteedStopLossOnFill
-type gSLOF struct {
-	Price       float64 `json:"price,omitempty,string"`
-	Distance    float64 `json:"distance,omitempty,string"`
+// OrderRequest is the specification of an order to create, the fields
+// used depend on the order type.
+type OrderRequest struct { // {{{
+	Type                     string                     `json:"type"`
+	Instrument               string                     `json:"instrument,omitempty"`
+	Units                    *Decimal                   `json:"units,omitempty"`
+	TimeInForce              string                     `json:"timeInForce"`
+	Price                    *Decimal                   `json:"price,omitempty"`
+	PriceBound               *Decimal                   `json:"priceBound,omitempty"`
+	PositionFill             string                     `json:"positionFill,omitempty"`
+	TakeProfitOnFill         *TakeProfitDetails         `json:"takeProfitOnFill,omitempty"`
+	StopLossOnFill           *StopLossDetails           `json:"stopLossOnFill,omitempty"`
+	TriggerCondition         string                     `json:"triggerCondition,omitempty"`
+	TradeID                  string                     `json:"tradeID,omitempty"`
+	ClientTradeID            string                     `json:"clientTradeID,omitempty"`
+	Distance                 *Decimal                   `json:"distance,omitempty"`
+	TrailingStopLossOnFill   *TrailingStopLossDetails   `json:"trailingStopLossOnFill,omitempty"`
+	GuaranteedStopLossOnFill *GuaranteedStopLossDetails `json:"guaranteedStopLossOnFill,omitempty"`
+} // }}}
+
+// TakeProfitDetails is the details of a Take Profit Order created for a trade.
+type TakeProfitDetails struct {
+	Price       Decimal `json:"price"`
+	TimeInForce string  `json:"timeInForce,omitempty"`
 	GtdTime     string  `json:"gtdTime,omitempty"`
-	TimeInForce string  `json:"timeInForce"`
 }
 
-type detailSLTP struct {
-	Price       float64 `json:"price,string"`
-	TimeInForce string  `json:"timeInForce"`
+// StopLossDetails is the details of a Stop Loss Order created for a trade,
+// only one of price and distance is set.
+type StopLossDetails struct {
+	Price       *Decimal `json:"price,omitempty"`
+	Distance    *Decimal `json:"distance,omitempty"`
+	TimeInForce string   `json:"timeInForce,omitempty"`
+	GtdTime     string   `json:"gtdTime,omitempty"`
+}
+
+// TrailingStopLossDetails is the details of a Trailing Stop Loss Order
+// created for a trade.
+type TrailingStopLossDetails struct {
+	Distance    Decimal `json:"distance"`
 	GtdTime     string  `json:"gtdTime,omitempty"`
+	TimeInForce string  `json:"timeInForce,omitempty"`
+}
+
+// GuaranteedStopLossDetails is the details of a Guaranteed Stop Loss Order
+// created for a trade, only one of price and distance is set.
+type GuaranteedStopLossDetails struct {
+	Price       *Decimal `json:"price,omitempty"`
+	Distance    *Decimal `json:"distance,omitempty"`
+	GtdTime     string   `json:"gtdTime,omitempty"`
+	TimeInForce string   `json:"timeInForce,omitempty"`
 }
 
-type configOpts func(*configOrder)
+// ConfigOpts is an option of the order to create.
+type ConfigOpts func(*configOrder)
 
 // extendOrderConfig is to add fields to current configuration after default config called.
-func (cf *configOrder) extendOrderConfig(config ...configOpts) {
+func (cf *configOrder) extendOrderConfig(config ...ConfigOpts) {
 	for _, conf := range config {
 		conf(cf)
 	}
@@ -155,11 +506,11 @@ type orderConfigFunc struct{}
 // is filled that opens a Trade requiring a Guaranteed Stop Loss, or when a
 // Trade’s dependent Guaranteed Stop Loss Order is modified directly through
 // the Trade.
-func (*orderConfigFunc) WithGuaranteedStopLossOnFill(price, distance float64, timeInForce, gtdTime string) configOpts {
+func (*orderConfigFunc) WithGuaranteedStopLossOnFill(price, distance Decimal, timeInForce, gtdTime string) ConfigOpts {
 	return func(co *configOrder) {
-		co.Order.GuaranteedStopLossOnFill = &gSLOF{}
-		co.Order.GuaranteedStopLossOnFill.Price = price
-		co.Order.GuaranteedStopLossOnFill.Distance = distance
+		co.Order.GuaranteedStopLossOnFill = &GuaranteedStopLossDetails{}
+		co.Order.GuaranteedStopLossOnFill.Price = optionalDecimal(price)
+		co.Order.GuaranteedStopLossOnFill.Distance = optionalDecimal(distance)
 		co.Order.GuaranteedStopLossOnFill.GtdTime = gtdTime
 		switch timeInForce {
 		case kw.TIMEINFORCE.GFD, kw.TIMEINFORCE.GTC, kw.TIMEINFORCE.GFD:
@@ -175,9 +526,9 @@ func (*orderConfigFunc) WithGuaranteedStopLossOnFill(price, distance float64, ti
 // is filled that opens a Trade requiring a Trailing Stop Loss, or when a
 // Trade’s dependent Trailing Stop Loss Order is modified directly through
 // the Trade.
-func (*orderConfigFunc) WithTrailingStopLossOnFill(distance float64, timeInForce, gtdTime string) configOpts {
+func (*orderConfigFunc) WithTrailingStopLossOnFill(distance Decimal, timeInForce, gtdTime string) ConfigOpts {
 	return func(co *configOrder) {
-		co.Order.TrailingStopLossOnFill = &tSLOF{}
+		co.Order.TrailingStopLossOnFill = &TrailingStopLossDetails{}
 		co.Order.TrailingStopLossOnFill.Distance = distance
 		co.Order.TrailingStopLossOnFill.GtdTime = gtdTime
 		switch timeInForce {
@@ -192,32 +543,32 @@ func (*orderConfigFunc) WithTrailingStopLossOnFill(distance float64, timeInForce
 // WithDistance is specifies the distance (in price units) from
 // the Trade’s open price to use as the Stop Loss Order price.
 // Only one of the distance and price fields may be specified.
-func (*orderConfigFunc) WithDistance(distance float64) configOpts {
-	return func(co *configOrder) { co.Order.Distance = distance }
+func (*orderConfigFunc) WithDistance(distance Decimal) ConfigOpts {
+	return func(co *configOrder) { co.Order.Distance = optionalDecimal(distance) }
 }
 
 // WithClientTradeID is the client ID of the Trade to be closed when
 // the price threshold is breached.
-func (*orderConfigFunc) WithClientTradeID(clientTradeID string) configOpts {
+func (*orderConfigFunc) WithClientTradeID(clientTradeID string) ConfigOpts {
 	return func(co *configOrder) { co.Order.ClientTradeID = clientTradeID }
 }
 
 // WithTradeID is he ID of the Trade to close when the price threshold is breached.
-func (*orderConfigFunc) WithTradeID(tradeID string) configOpts {
+func (*orderConfigFunc) WithTradeID(tradeID string) ConfigOpts {
 	return func(co *configOrder) { co.Order.TradeID = tradeID }
 }
 
 // WithPrice is the price that the Stop Loss Order will be triggered at.
 // Only one of the price and distance fields may be specified.
-func (*orderConfigFunc) WithPrice(price float64) configOpts {
-	return func(co *configOrder) { co.Order.Price = price }
+func (*orderConfigFunc) WithPrice(price Decimal) ConfigOpts {
+	return func(co *configOrder) { co.Order.Price = optionalDecimal(price) }
 }
 
 // WithUnits is the quantity requested to be filled by the Market Order. A positive
 // number of units results in a long Order, and a negative number of units
 // results in a short Order.
-func (*orderConfigFunc) WithUnits(units float64) configOpts {
-	return func(co *configOrder) { co.Order.Units = units }
+func (*orderConfigFunc) WithUnits(units Decimal) ConfigOpts {
+	return func(co *configOrder) { co.Order.Units = optionalDecimal(units) }
 }
 
 // WithTriggerCondition is specification of which price component should
@@ -237,7 +588,7 @@ func (*orderConfigFunc) WithUnits(units float64) configOpts {
 // must either be “DEFAULT”, or the “natural” trigger side “DEFAULT” results in.
 // So for a Guaranteed Stop Loss Order for a long trade valid values are
 // “DEFAULT” and “BID”, and for short trades “DEFAULT” and “ASK” are valid.
-func (*orderConfigFunc) WithTriggerCondition(triggerCondition string) configOpts {
+func (*orderConfigFunc) WithTriggerCondition(triggerCondition string) ConfigOpts {
 	return func(co *configOrder) { co.Order.TriggerCondition = triggerCondition }
 }
 
@@ -245,12 +596,12 @@ func (*orderConfigFunc) WithTriggerCondition(triggerCondition string) configOpts
 // on behalf of a client. This may happen when an Order is filled that opens
 // a Trade requiring a Stop Loss, or when a Trade’s dependent Stop Loss
 // Order is modified directly through the Trade.
-func (*orderConfigFunc) WithStopLossOnFill(gtdTime, timeInForce string, price float64) configOpts {
+func (*orderConfigFunc) WithStopLossOnFill(gtdTime, timeInForce string, price Decimal) ConfigOpts {
 	return func(co *configOrder) {
-		co.Order.StopLossOnFill = &detailSLTP{}
+		co.Order.StopLossOnFill = &StopLossDetails{}
 		co.Order.StopLossOnFill.GtdTime = gtdTime
 		co.Order.StopLossOnFill.TimeInForce = timeInForce
-		co.Order.StopLossOnFill.Price = price
+		co.Order.StopLossOnFill.Price = optionalDecimal(price)
 	}
 }
 
@@ -258,9 +609,9 @@ func (*orderConfigFunc) WithStopLossOnFill(gtdTime, timeInForce string, price fl
 // created on behalf of a client. This may happen when an Order is filled
 // that opens a Trade requiring a Take Profit, or when a Trade’s dependent
 // Take Profit Order is modified directly through the Trade.
-func (*orderConfigFunc) WithTakeProfitOnFill(gtdTime, timeInForce string, price float64) configOpts {
+func (*orderConfigFunc) WithTakeProfitOnFill(gtdTime, timeInForce string, price Decimal) ConfigOpts {
 	return func(co *configOrder) {
-		co.Order.TakeProfitOnFill = &detailSLTP{}
+		co.Order.TakeProfitOnFill = &TakeProfitDetails{}
 		co.Order.TakeProfitOnFill.GtdTime = gtdTime
 		co.Order.TakeProfitOnFill.TimeInForce = timeInForce
 		co.Order.TakeProfitOnFill.Price = price
@@ -269,26 +620,26 @@ func (*orderConfigFunc) WithTakeProfitOnFill(gtdTime, timeInForce string, price
 
 // WithPositionFill is specification of how Positions in the Account
 // are modified when the Order is filled.
-func (*orderConfigFunc) WithPositionFill(positionFill string) configOpts {
+func (*orderConfigFunc) WithPositionFill(positionFill string) ConfigOpts {
 	return func(co *configOrder) { co.Order.PositionFill = positionFill }
 }
 
 // WithInstrument is the Market Order’s Instrument.
-func (*orderConfigFunc) WithInstrument(instrument string) configOpts {
+func (*orderConfigFunc) WithInstrument(instrument string) ConfigOpts {
 	return func(co *configOrder) { co.Order.Instrument = instrument }
 }
 
 // WithTimeInForce is the time-in-force requested for the Market Order. Restricted to FOK or IOC for a MarketOrder.
-func (*orderConfigFunc) WithTimeInForce(timeInForce string) configOpts {
+func (*orderConfigFunc) WithTimeInForce(timeInForce string) ConfigOpts {
 	return func(co *configOrder) {
 		co.Order.TimeInForce = timeInForce
 	}
 }
 
 // WithPriceBound is the worst price that the client is willing to have the Market Order filled at.
-func (*orderConfigFunc) WithPriceBound(priceBound float64) configOpts {
+func (*orderConfigFunc) WithPriceBound(priceBound Decimal) ConfigOpts {
 	return func(co *configOrder) {
-		co.Order.PriceBound = priceBound
+		co.Order.PriceBound = optionalDecimal(priceBound)
 	}
 } // }}}
 
@@ -317,43 +668,46 @@ func (cf *configOrder) defaultConfig() {
 func (cf *configOrder) convertConfig() ([]byte, error) {
 	switch cf.Order.Type {
 	case kw.ORDERTYPE.MARKET:
-		cf.Order.Price = 0
+		cf.Order.Price = nil
 		cf.Order.TriggerCondition = ""
 		cf.Order.ClientTradeID = ""
 		cf.Order.TradeID = ""
-		cf.Order.Distance = 0
+		cf.Order.Distance = nil
 	case kw.ORDERTYPE.LIMIT:
-		cf.Order.PriceBound = 0
+		cf.Order.PriceBound = nil
 		cf.Order.ClientTradeID = ""
 		cf.Order.TradeID = ""
-		cf.Order.Distance = 0
+		cf.Order.Distance = nil
 	case kw.ORDERTYPE.MARKET_IF_TOUCHED, kw.ORDERTYPE.STOP:
 		cf.Order.ClientTradeID = ""
 		cf.Order.TradeID = ""
-		cf.Order.Distance = 0
+		cf.Order.Distance = nil
 	case kw.ORDERTYPE.TAKE_PROFIT:
 		cf.Order.Instrument = ""
-		cf.Order.Units = 0
-		cf.Order.PriceBound = 0
+		cf.Order.Units = nil
+		cf.Order.PriceBound = nil
 		cf.Order.PositionFill = ""
 		cf.Order.TakeProfitOnFill = nil
 		cf.Order.StopLossOnFill = nil
-		cf.Order.Distance = 0
+		cf.Order.Distance = nil
 		cf.Order.GuaranteedStopLossOnFill = nil
 		cf.Order.TrailingStopLossOnFill = nil
 	case kw.ORDERTYPE.STOP_LOSS:
 		cf.Order.Instrument = ""
-		cf.Order.Units = 0
-		cf.Order.PriceBound = 0
+		cf.Order.Units = nil
+		cf.Order.PriceBound = nil
 		cf.Order.PositionFill = ""
 		cf.Order.TakeProfitOnFill = nil
 		cf.Order.StopLossOnFill = nil
 		cf.Order.GuaranteedStopLossOnFill = nil
 		cf.Order.TrailingStopLossOnFill = nil
-	case kw.ORDERTYPE.GUARANTEED_STOP_LOSS, kw.ORDERTYPE.TRAILING_STOP_LOSS:
+	case kw.ORDERTYPE.TRAILING_STOP_LOSS:
+		cf.Order.Price = nil
+		fallthrough
+	case kw.ORDERTYPE.GUARANTEED_STOP_LOSS:
 		cf.Order.Instrument = ""
-		cf.Order.Units = 0
-		cf.Order.PriceBound = 0
+		cf.Order.Units = nil
+		cf.Order.PriceBound = nil
 		cf.Order.PositionFill = ""
 		cf.Order.TakeProfitOnFill = nil
 		cf.Order.StopLossOnFill = nil
@@ -370,55 +724,157 @@ func (cf *configOrder) convertConfig() ([]byte, error) {
 // ----------------  ORDER MAIN FUNCTION-------------------------
 
 // Get a list of Orders for an Account
-func (od *order) GetOrderList(live bool, accountID string, querys ...orderOpts) (string, error) { // {{{
+func (od *OrderService) GetOrderList(querys ...OrderOpts) (*OrderList, error) {
+	return od.GetOrderListContext(context.Background(), querys...)
+}
+
+// GetOrderListContext is GetOrderList with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) GetOrderListContext(ctx context.Context, querys ...OrderOpts) (*OrderList, error) { // {{{
 	q := newOrderQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	ep = fmt.Sprintf(ep, accountID)
+	ep := od.getEndpoint(endpoint.Order.Orders)
+	ep = fmt.Sprintf(ep, od.client.accountID)
 	url, err := urlAddQuery(ep, q)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	od.endpoint = url
-	od.method = http.MethodGet
-	resp, err := od.connect()
-	if err != nil {
-		return "", err
+	result := &OrderList{}
+	if err = od.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	return string(resp), nil
+	return result, nil
 } // }}}
 
 // GetPendingOrders is to list all pending Orders in an Account
-func (od *order) GetPendingOrders(live bool, accountID string) (string, error) { // {{{
-	ep := endpoint.GetEndpoint(live, endpoint.Order.PendingOrder)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	od.method = http.MethodGet
-	resp, err := od.connect()
-	if err != nil {
-		return string(resp), err
+func (od *OrderService) GetPendingOrders() (*OrderList, error) {
+	return od.GetPendingOrdersContext(context.Background())
+}
+
+// GetPendingOrdersContext is GetPendingOrders with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) GetPendingOrdersContext(ctx context.Context) (*OrderList, error) { // {{{
+	ep := od.getEndpoint(endpoint.Order.PendingOrder)
+	url := fmt.Sprintf(ep, od.client.accountID)
+	result := &OrderList{}
+	if err := od.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	return string(resp), err
+	return result, nil
 } // }}}
 
 // GetOrderDetails is to get details for a single Order in an Account
-func (od *order) GetOrderDetails(live bool, accountID, tradeID string) (string, error) { // {{{
-	ep := endpoint.GetEndpoint(live, endpoint.Order.OrderDetails)
-	od.endpoint = fmt.Sprintf(ep, accountID, tradeID)
-	od.method = http.MethodGet
-	resp, err := od.connect()
+func (od *OrderService) GetOrderDetails(orderID string) (*OrderDetails, error) {
+	return od.GetOrderDetailsContext(context.Background(), orderID)
+}
+
+// GetOrderDetailsContext is GetOrderDetails with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) GetOrderDetailsContext(ctx context.Context, orderID string) (*OrderDetails, error) { // {{{
+	ep := od.getEndpoint(endpoint.Order.OrderDetails)
+	url := fmt.Sprintf(ep, od.client.accountID, orderID)
+	result := &OrderDetails{}
+	if err := od.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+} // }}}
+
+// ReplaceOrder is to replace a pending Order of an Account by an Order of
+// orderType, e.g. kw.ORDERTYPE.LIMIT, built from opts like the order requests.
+// The Order replaced is given by its ID or by its client ID prefixed with @.
+// An order rejected is an *APIError with the reject transaction.
+func (od *OrderService) ReplaceOrder(orderSpecifier, orderType string, opts ...ConfigOpts) (*OrderReplaceResponse, error) {
+	return od.ReplaceOrderContext(context.Background(), orderSpecifier, orderType, opts...)
+}
+
+// ReplaceOrderContext is ReplaceOrder with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) ReplaceOrderContext(ctx context.Context, orderSpecifier, orderType string, opts ...ConfigOpts) (*OrderReplaceResponse, error) { // {{{
+	conf := &configOrder{}
+	conf.Order.Type = orderType
+	conf.defaultConfig()
+	conf.extendOrderConfig(opts...)
+	data, err := conf.convertConfig()
 	if err != nil {
-		return string(resp), err
+		return nil, err
+	}
+	ep := od.getEndpoint(endpoint.Order.ReplaceOrder)
+	url := fmt.Sprintf(ep, od.client.accountID, orderSpecifier)
+	result := &OrderReplaceResponse{}
+	if err = od.connectJSON(ctx, &request{method: http.MethodPut, endpoint: url, data: data}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+} // }}}
+
+// CancelOrder is to cancel a pending Order of an Account, the Order is
+// given by its ID or by its client ID prefixed with @.
+func (od *OrderService) CancelOrder(orderSpecifier string) (*OrderCancelResponse, error) {
+	return od.CancelOrderContext(context.Background(), orderSpecifier)
+}
+
+// CancelOrderContext is CancelOrder with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) CancelOrderContext(ctx context.Context, orderSpecifier string) (*OrderCancelResponse, error) { // {{{
+	ep := od.getEndpoint(endpoint.Order.CancelOrder)
+	url := fmt.Sprintf(ep, od.client.accountID, orderSpecifier)
+	result := &OrderCancelResponse{}
+	if err := od.connectJSON(ctx, &request{method: http.MethodPut, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	return string(resp), nil
+	return result, nil
 } // }}}
 
-func PutOrderReplace() {}
+// UpdateOrderClientExtensions is to update the client extensions of an Order
+// and of the Trade opened when the Order is filled, a nil extensions is
+// left unchanged. The Order is given by its ID or by its client ID prefixed
+// with @. Client extensions must not be set for an Account used by MT4.
+func (od *OrderService) UpdateOrderClientExtensions(orderSpecifier string, clientExtensions, tradeClientExtensions *ClientExtensions) (*OrderClientExtensionsResponse, error) {
+	return od.UpdateOrderClientExtensionsContext(context.Background(), orderSpecifier, clientExtensions, tradeClientExtensions)
+}
 
-func PutOrderCancel() {}
+// UpdateOrderClientExtensionsContext is UpdateOrderClientExtensions with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) UpdateOrderClientExtensionsContext(ctx context.Context, orderSpecifier string, clientExtensions, tradeClientExtensions *ClientExtensions) (*OrderClientExtensionsResponse, error) { // {{{
+	body, err := json.Marshal(struct {
+		ClientExtensions      *ClientExtensions `json:"clientExtensions,omitempty"`
+		TradeClientExtensions *ClientExtensions `json:"tradeClientExtensions,omitempty"`
+	}{clientExtensions, tradeClientExtensions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal client extensions, %v", err)
+	}
+	ep := od.getEndpoint(endpoint.Order.UpdateClientExt)
+	url := fmt.Sprintf(ep, od.client.accountID, orderSpecifier)
+	result := &OrderClientExtensionsResponse{}
+	if err = od.connectJSON(ctx, &request{method: http.MethodPut, endpoint: url, data: body}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+} // }}}
 
-func PutOrderUpdateClientExt() {}
+// createOrder is to post the order of conf.
+func (od *OrderService) createOrder(ctx context.Context, conf *configOrder) (*OrderCreateResponse, error) {
+	data, err := conf.convertConfig()
+	if err != nil {
+		return nil, err
+	}
+	ep := od.getEndpoint(endpoint.Order.Orders)
+	url := fmt.Sprintf(ep, od.client.accountID)
+	result := &OrderCreateResponse{}
+	if err = od.connectJSON(ctx, &request{method: http.MethodPost, endpoint: url, data: data}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
 // MarketOrderRequest specifies the parameters that may be set when creating a Market Order.
-func (od *order) MarketOrderRequest(live bool, accountID, instrument string, units float64, opts ...configOpts) (string, error) { // {{{
+func (od *OrderService) MarketOrderRequest(instrument string, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.MarketOrderRequestContext(context.Background(), instrument, units, opts...)
+}
+
+// MarketOrderRequestContext is MarketOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) MarketOrderRequestContext(ctx context.Context, instrument string, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.MARKET
 	conf.defaultConfig()
@@ -426,23 +882,17 @@ func (od *order) MarketOrderRequest(live bool, accountID, instrument string, uni
 	conf.extendOrderConfig(
 		od.Config.WithInstrument(instrument),
 		od.Config.WithUnits(units))
-	data, err := conf.convertConfig()
-	if err != nil {
-		return "", err
-	}
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		return "", err
-	}
-	return string(resp), nil
-}
+	return od.createOrder(ctx, conf)
+} // }}}
 
 // LimitOrderRequest specifies the parameters that may be set when creating a Limit Order.
-func (od *order) LimitOrderRequest(live bool, accountID, instrument string, price, units float64, opts ...configOpts) {
+func (od *OrderService) LimitOrderRequest(instrument string, price, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.LimitOrderRequestContext(context.Background(), instrument, price, units, opts...)
+}
+
+// LimitOrderRequestContext is LimitOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) LimitOrderRequestContext(ctx context.Context, instrument string, price, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.LIMIT
 	conf.defaultConfig()
@@ -451,24 +901,17 @@ func (od *order) LimitOrderRequest(live bool, accountID, instrument string, pric
 		od.Config.WithInstrument(instrument),
 		od.Config.WithUnits(units),
 		od.Config.WithPrice(price))
-	data, err := conf.convertConfig()
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Printf("order created with below configuration:\n%v\n", string(data))
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println(string(resp))
+	return od.createOrder(ctx, conf)
 } // }}}
 
 // StopOrderRequest specifies the parameters that may be set when creating a Stop Order.
-func (od *order) StopOrderRequest(live bool, accountID, instrument string, price, units float64, opts ...configOpts) (string, error) { // {{{
+func (od *OrderService) StopOrderRequest(instrument string, price, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.StopOrderRequestContext(context.Background(), instrument, price, units, opts...)
+}
+
+// StopOrderRequestContext is StopOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) StopOrderRequestContext(ctx context.Context, instrument string, price, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.STOP
 	conf.defaultConfig()
@@ -477,23 +920,17 @@ func (od *order) StopOrderRequest(live bool, accountID, instrument string, price
 		od.Config.WithInstrument(instrument),
 		od.Config.WithUnits(units),
 		od.Config.WithPrice(price))
-	data, err := conf.convertConfig()
-	if err != nil {
-		return "", err
-	}
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		return "", err
-	}
-	return string(resp), nil
+	return od.createOrder(ctx, conf)
 } // }}}
 
 // MarketIfTouchedOrderRequest specifies the parameters that may be set when creating a Market-if-Touched Order.
-func (od *order) MarketIfTouchedOrderRequest(live bool, accountID, instrument string, price, units float64, opts ...configOpts) (string, error) { // {{{
+func (od *OrderService) MarketIfTouchedOrderRequest(instrument string, price, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.MarketIfTouchedOrderRequestContext(context.Background(), instrument, price, units, opts...)
+}
+
+// MarketIfTouchedOrderRequestContext is MarketIfTouchedOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) MarketIfTouchedOrderRequestContext(ctx context.Context, instrument string, price, units Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.MARKET_IF_TOUCHED
 	conf.defaultConfig()
@@ -502,24 +939,18 @@ func (od *order) MarketIfTouchedOrderRequest(live bool, accountID, instrument st
 		od.Config.WithInstrument(instrument),
 		od.Config.WithUnits(units),
 		od.Config.WithPrice(price))
-	data, err := conf.convertConfig()
-	if err != nil {
-		return "", err
-	}
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		return string(resp), err
-	}
-	return string(resp), nil
+	return od.createOrder(ctx, conf)
 } // }}}
 
 // TakeProfitOrderRequest specifies the parameters that may be
 // set when creating a Take Profit Order.
-func (od *order) TakeProfitOrderRequest(live bool, accountID, tradeID string, price float64, opts ...configOpts) (string, error) { // {{{
+func (od *OrderService) TakeProfitOrderRequest(tradeID string, price Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.TakeProfitOrderRequestContext(context.Background(), tradeID, price, opts...)
+}
+
+// TakeProfitOrderRequestContext is TakeProfitOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) TakeProfitOrderRequestContext(ctx context.Context, tradeID string, price Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.TAKE_PROFIT
 	conf.defaultConfig()
@@ -527,26 +958,19 @@ func (od *order) TakeProfitOrderRequest(live bool, accountID, tradeID string, pr
 	conf.extendOrderConfig(
 		od.Config.WithTradeID(tradeID),
 		od.Config.WithPrice(price))
-	data, err := conf.convertConfig()
-	if err != nil {
-		return "", err
-	}
-	fmt.Printf("order created with below configuration:\n%v\n", string(data))
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		return string(resp), err
-	}
-	return string(resp), nil
+	return od.createOrder(ctx, conf)
 } // }}}
 
 // StopLossOrderRequest specifies the parameters that may be set
 // when creating a Stop Loss Order. Only one of the price and
 // distance fields may be specified.
-func (od *order) StopLossOrderRequest(live bool, accountID, tradeID string, price float64, opts ...configOpts) (string, error) { // {{{
+func (od *OrderService) StopLossOrderRequest(tradeID string, price Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.StopLossOrderRequestContext(context.Background(), tradeID, price, opts...)
+}
+
+// StopLossOrderRequestContext is StopLossOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) StopLossOrderRequestContext(ctx context.Context, tradeID string, price Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.STOP_LOSS
 	conf.defaultConfig()
@@ -554,25 +978,19 @@ func (od *order) StopLossOrderRequest(live bool, accountID, tradeID string, pric
 	conf.extendOrderConfig(
 		od.Config.WithTradeID(tradeID),
 		od.Config.WithPrice(price))
-	data, err := conf.convertConfig()
-	if err != nil {
-		return "", err
-	}
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		return string(resp), err
-	}
-	return string(resp), nil
+	return od.createOrder(ctx, conf)
 } // }}}
 
 // GuaranteedStopLossOrderRequest specifies the parameters that
 // may be set when creating a Guaranteed Stop Loss Order.
 // Only one of the price and distance fields may be specified.
-func (od *order) GuaranteedStopLossOrderRequest(live bool, accountID, tradeID string, price float64, opts ...configOpts) (string, error) { // {{{
+func (od *OrderService) GuaranteedStopLossOrderRequest(tradeID string, price Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.GuaranteedStopLossOrderRequestContext(context.Background(), tradeID, price, opts...)
+}
+
+// GuaranteedStopLossOrderRequestContext is GuaranteedStopLossOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) GuaranteedStopLossOrderRequestContext(ctx context.Context, tradeID string, price Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.GUARANTEED_STOP_LOSS
 	conf.defaultConfig()
@@ -580,42 +998,24 @@ func (od *order) GuaranteedStopLossOrderRequest(live bool, accountID, tradeID st
 	conf.extendOrderConfig(
 		od.Config.WithTradeID(tradeID),
 		od.Config.WithPrice(price))
-	data, err := conf.convertConfig()
-	if err != nil {
-		return "", err
-	}
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		return string(resp), err
-	}
-	return string(resp), nil
+	return od.createOrder(ctx, conf)
 } // }}}
 
 // TrailingStopLossOrderRequest specifies the parameters that
 // may be set when creating a Trailing Stop Loss Order.
-func (od *order) TrailingStopLossOrderRequest(live bool, accountID, tradeID string, distance float64, opts ...configOpts) (string, error) { // {{{
+func (od *OrderService) TrailingStopLossOrderRequest(tradeID string, distance Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) {
+	return od.TrailingStopLossOrderRequestContext(context.Background(), tradeID, distance, opts...)
+}
+
+// TrailingStopLossOrderRequestContext is TrailingStopLossOrderRequest with a context to cancel the request
+// or to set its deadline.
+func (od *OrderService) TrailingStopLossOrderRequestContext(ctx context.Context, tradeID string, distance Decimal, opts ...ConfigOpts) (*OrderCreateResponse, error) { // {{{
 	conf := &configOrder{}
 	conf.Order.Type = kw.ORDERTYPE.TRAILING_STOP_LOSS
 	conf.defaultConfig()
 	conf.extendOrderConfig(opts...)
 	conf.extendOrderConfig(
 		od.Config.WithTradeID(tradeID),
-		od.Config.WithPrice(distance))
-	data, err := conf.convertConfig()
-	if err != nil {
-		return "", err
-	}
-	od.data = data
-	od.method = http.MethodPost
-	ep := endpoint.GetEndpoint(live, endpoint.Order.Orders)
-	od.endpoint = fmt.Sprintf(ep, accountID)
-	resp, err := od.connect()
-	if err != nil {
-		return string(resp), err
-	}
-	return string(resp), nil
+		od.Config.WithDistance(distance))
+	return od.createOrder(ctx, conf)
 } // }}}
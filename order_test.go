@@ -0,0 +1,217 @@
+package gooanda_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/kokweikhong/gooanda"
+	"github.com/kokweikhong/gooanda/kw"
+)
+
+const (
+	testLimitOrder    = `{"id":"10","createTime":"2021-01-01T00:00:00.000000000Z","state":"PENDING","type":"LIMIT","instrument":"EUR_USD","units":"100","price":"1.20000","timeInForce":"GTC","positionFill":"DEFAULT","triggerCondition":"DEFAULT","takeProfitOnFill":{"price":"1.25000","timeInForce":"GTC"}}`
+	testStopLossOrder = `{"id":"11","createTime":"2021-01-01T00:00:00.000000000Z","state":"PENDING","type":"STOP_LOSS","tradeID":"7","price":"1.10000","timeInForce":"GTC","triggerCondition":"DEFAULT","clientExtensions":{"id":"my-sl"}}`
+)
+
+func TestGetOrderList(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/orders" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"orders":[%v,%v,{"id":"12","type":"NEW_TYPE"}],"lastTransactionID":"12"}`,
+			testLimitOrder, testStopLossOrder)
+	})
+	list, err := client.Orders().GetOrderList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Orders) != 3 || list.LastTransactionID != "12" {
+		t.Fatalf("list = %+v", list)
+	}
+	limit, ok := list.Orders[0].(*gooanda.LimitOrder)
+	if !ok {
+		t.Fatalf("orders[0] = %T, want *gooanda.LimitOrder", list.Orders[0])
+	}
+	if limit.ID != "10" || !limit.Price.Equal(dec("1.2")) || !limit.Units.Equal(dec("100")) || !limit.TakeProfitOnFill.Price.Equal(dec("1.25")) {
+		t.Errorf("limit order = %+v", limit)
+	}
+	stopLoss, ok := list.Orders[1].(*gooanda.StopLossOrder)
+	if !ok {
+		t.Fatalf("orders[1] = %T, want *gooanda.StopLossOrder", list.Orders[1])
+	}
+	if stopLoss.TradeID != "7" || stopLoss.ClientExtensions.ID != "my-sl" {
+		t.Errorf("stop loss order = %+v", stopLoss)
+	}
+	if base := list.Orders[2].Base(); base.ID != "12" || base.Type != "NEW_TYPE" {
+		t.Errorf("orders[2] = %+v", base)
+	}
+}
+
+func TestGetOrderDetails(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/orders/11" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"order":%v,"lastTransactionID":"12"}`, testStopLossOrder)
+	})
+	details, err := client.Orders().GetOrderDetails("11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, ok := details.Order.(*gooanda.StopLossOrder); !ok || !o.Price.Equal(dec("1.1")) {
+		t.Errorf("order = %#v", details.Order)
+	}
+}
+
+func TestMarketOrderRequest(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			Order map[string]interface{} `json:"order"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+		}
+		if req.Order["type"] != "MARKET" || req.Order["units"] != "-100" {
+			t.Errorf("order = %s", body)
+		}
+		for _, key := range []string{"price", "priceBound", "distance"} {
+			if _, ok := req.Order[key]; ok {
+				t.Errorf("order = %s, want no %v", body, key)
+			}
+		}
+		fmt.Fprint(w, `{"orderCreateTransaction":{"id":"6","type":"MARKET_ORDER","instrument":"EUR_USD","units":"-100","timeInForce":"FOK","positionFill":"DEFAULT","reason":"CLIENT_ORDER"},`+
+			`"orderFillTransaction":{"id":"7","type":"ORDER_FILL","orderID":"6","instrument":"EUR_USD","units":"-100","fullVWAP":"1.22000","pl":"0.0000","financing":"0.0000","commission":"0.0000","accountBalance":"100000.0000","reason":"MARKET_ORDER",`+
+			`"tradeOpened":{"tradeID":"7","units":"-100","price":"1.22000"}},"relatedTransactionIDs":["6","7"],"lastTransactionID":"7"}`)
+	})
+	resp, err := client.Orders().MarketOrderRequest("EUR_USD", dec("-100"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created, ok := resp.OrderCreateTransaction.(*gooanda.MarketOrderTransaction); !ok || created.Reason != "CLIENT_ORDER" {
+		t.Errorf("orderCreateTransaction = %#v", resp.OrderCreateTransaction)
+	}
+	fill := resp.OrderFillTransaction
+	if fill == nil || fill.OrderID != "6" || !fill.FullVWAP.Equal(dec("1.22")) || fill.TradeOpened.TradeID != "7" {
+		t.Errorf("orderFillTransaction = %+v", fill)
+	}
+	if resp.OrderCancelTransaction != nil || len(resp.RelatedTransactionIDs) != 2 {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestTrailingStopLossOrderRequest(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			Order map[string]interface{} `json:"order"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+		}
+		if req.Order["tradeID"] != "7" || req.Order["distance"] != "0.005" || req.Order["price"] != nil {
+			t.Errorf("order = %s", body)
+		}
+		fmt.Fprint(w, `{"orderCreateTransaction":{"id":"8","type":"TRAILING_STOP_LOSS_ORDER","tradeID":"7","distance":"0.00500","timeInForce":"GTC"},"lastTransactionID":"8"}`)
+	})
+	resp, err := client.Orders().TrailingStopLossOrderRequest("7", dec("0.005"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created, ok := resp.OrderCreateTransaction.(*gooanda.TrailingStopLossOrderTransaction); !ok || !created.Distance.Equal(dec("0.005")) {
+		t.Errorf("orderCreateTransaction = %#v", resp.OrderCreateTransaction)
+	}
+}
+
+func TestReplaceOrder(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v3/accounts/101-001-1-001/orders/10" {
+			t.Errorf("request = %v %v", r.Method, r.URL.Path)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			Order map[string]interface{} `json:"order"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+		}
+		if req.Order["type"] != "LIMIT" || req.Order["price"] != "1.21" || req.Order["units"] != "200" {
+			t.Errorf("order = %s", body)
+		}
+		fmt.Fprint(w, `{"orderCancelTransaction":{"id":"13","type":"ORDER_CANCEL","orderID":"10","reason":"CLIENT_REQUEST_REPLACED","replacedByOrderID":"14"},`+
+			`"orderCreateTransaction":{"id":"14","type":"LIMIT_ORDER","instrument":"EUR_USD","units":"200","price":"1.21000","reason":"REPLACEMENT","replacesOrderID":"10"},`+
+			`"relatedTransactionIDs":["13","14"],"lastTransactionID":"14"}`)
+	})
+	od := client.Orders()
+	resp, err := od.ReplaceOrder("10", kw.ORDERTYPE.LIMIT,
+		od.Config.WithInstrument("EUR_USD"), od.Config.WithUnits(dec("200")), od.Config.WithPrice(dec("1.21")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.OrderCancelTransaction.ReplacedByOrderID != "14" {
+		t.Errorf("orderCancelTransaction = %+v", resp.OrderCancelTransaction)
+	}
+	if created, ok := resp.OrderCreateTransaction.(*gooanda.LimitOrderTransaction); !ok || created.ReplacesOrderID != "10" {
+		t.Errorf("orderCreateTransaction = %#v", resp.OrderCreateTransaction)
+	}
+	if resp.ReplacingOrderCancelTransaction != nil {
+		t.Errorf("replacingOrderCancelTransaction = %+v", resp.ReplacingOrderCancelTransaction)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/accounts/101-001-1-001/orders/@unknown/cancel" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"orderCancelRejectTransaction":{"id":"16","type":"ORDER_CANCEL_REJECT","clientOrderID":"unknown","rejectReason":"ORDER_DOESNT_EXIST"},"lastTransactionID":"16"}`)
+			return
+		}
+		if r.Method != http.MethodPut || r.URL.Path != "/v3/accounts/101-001-1-001/orders/@my-order/cancel" {
+			t.Errorf("request = %v %v", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"orderCancelTransaction":{"id":"15","type":"ORDER_CANCEL","orderID":"10","clientOrderID":"my-order","reason":"CLIENT_REQUEST"},"relatedTransactionIDs":["15"],"lastTransactionID":"15"}`)
+	})
+	resp, err := client.Orders().CancelOrder("@my-order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancel := resp.OrderCancelTransaction; cancel.OrderID != "10" || cancel.Reason != "CLIENT_REQUEST" {
+		t.Errorf("orderCancelTransaction = %+v", cancel)
+	}
+
+	_, err = client.Orders().CancelOrder("@unknown")
+	var apiErr *gooanda.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	reject, err := apiErr.Reject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := reject.(*gooanda.OrderCancelRejectTransaction); !ok || r.RejectReason != "ORDER_DOESNT_EXIST" {
+		t.Errorf("reject = %#v", reject)
+	}
+}
+
+func TestUpdateOrderClientExtensions(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v3/accounts/101-001-1-001/orders/10/clientExtensions" {
+			t.Errorf("request = %v %v", r.Method, r.URL.Path)
+		}
+		if body, _ := ioutil.ReadAll(r.Body); string(body) != `{"clientExtensions":{"id":"my-order","tag":"strategy-1"}}` {
+			t.Errorf("body = %s", body)
+		}
+		fmt.Fprint(w, `{"orderClientExtensionsModifyTransaction":{"id":"17","type":"ORDER_CLIENT_EXTENSIONS_MODIFY","orderID":"10","clientExtensionsModify":{"id":"my-order","tag":"strategy-1"}},"lastTransactionID":"17"}`)
+	})
+	resp, err := client.Orders().UpdateOrderClientExtensions("10", &gooanda.ClientExtensions{ID: "my-order", Tag: "strategy-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modify := resp.OrderClientExtensionsModifyTransaction; modify.ClientExtensionsModify.Tag != "strategy-1" {
+		t.Errorf("orderClientExtensionsModifyTransaction = %+v", modify)
+	}
+}
@@ -1,71 +1,138 @@
 package gooanda
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/kokweikhong/gooanda/endpoint"
 )
 
-type position struct {
-	connection
+// PositionList is the response of GetPositionList and GetOpenPositionList.
+type PositionList struct {
+	Positions         []Position `json:"positions"`
+	LastTransactionID string     `json:"lastTransactionID"`
 }
 
-// NewPositionConnection is to create connection for POSITION API.
-func NewPositionConnection(token string) *position {
-	conn := &position{}
-	conn.token = token
-	return conn
+// PositionDetails is the response of GetOpenPositionForInstrument.
+type PositionDetails struct {
+	Position          Position `json:"position"`
+	LastTransactionID string   `json:"lastTransactionID"`
+}
+
+// Position is the position of an Account for an instrument.
+type Position struct { // {{{
+	Instrument              string       `json:"instrument"`
+	PL                      Decimal      `json:"pl"`
+	UnrealizedPL            Decimal      `json:"unrealizedPL"`
+	MarginUsed              Decimal      `json:"marginUsed"`
+	ResettablePL            Decimal      `json:"resettablePL"`
+	Financing               Decimal      `json:"financing"`
+	Commission              Decimal      `json:"commission"`
+	DividendAdjustment      Decimal      `json:"dividendAdjustment"`
+	GuaranteedExecutionFees Decimal      `json:"guaranteedExecutionFees"`
+	Long                    PositionSide `json:"long"`
+	Short                   PositionSide `json:"short"`
+} // }}}
+
+// PositionSide is the long or the short side of a Position.
+type PositionSide struct { // {{{
+	Units                   Decimal  `json:"units"`
+	AveragePrice            Decimal  `json:"averagePrice"`
+	TradeIDs                []string `json:"tradeIDs,omitempty"`
+	PL                      Decimal  `json:"pl"`
+	UnrealizedPL            Decimal  `json:"unrealizedPL"`
+	ResettablePL            Decimal  `json:"resettablePL"`
+	Financing               Decimal  `json:"financing"`
+	DividendAdjustment      Decimal  `json:"dividendAdjustment"`
+	GuaranteedExecutionFees Decimal  `json:"guaranteedExecutionFees"`
+} // }}}
+
+// ClosePositionResponse is the response of CloseOpenPositionForInstrument,
+// a side is closed by a MarketOrder which is filled or cancelled, only the
+// transactions of the side closed are set.
+type ClosePositionResponse struct { // {{{
+	LongOrderCreateTransaction  *MarketOrderTransaction `json:"longOrderCreateTransaction,omitempty"`
+	LongOrderFillTransaction    *OrderFillTransaction   `json:"longOrderFillTransaction,omitempty"`
+	LongOrderCancelTransaction  *OrderCancelTransaction `json:"longOrderCancelTransaction,omitempty"`
+	ShortOrderCreateTransaction *MarketOrderTransaction `json:"shortOrderCreateTransaction,omitempty"`
+	ShortOrderFillTransaction   *OrderFillTransaction   `json:"shortOrderFillTransaction,omitempty"`
+	ShortOrderCancelTransaction *OrderCancelTransaction `json:"shortOrderCancelTransaction,omitempty"`
+	RelatedTransactionIDs       []string                `json:"relatedTransactionIDs"`
+	LastTransactionID           string                  `json:"lastTransactionID"`
+} // }}}
+
+// PositionService is the POSITION API of Client.Positions.
+type PositionService struct {
+	connection
 }
 
 // GetPositionList is to list all Positions for an Account.
 // The Positions returned are for every instrument that has had a position
 // during the lifetime of an the Account.
-func (ps *position) GetPositionList(live bool, accountID string) {
-	ep := endpoint.GetEndpoint(live, endpoint.Position.PositionList)
-	ps.endpoint = fmt.Sprintf(ep, accountID)
-	fmt.Println(ps.endpoint)
-	ps.method = http.MethodGet
-	data, err := ps.connect()
-	if err != nil {
-		log.Fatal(err)
+func (ps *PositionService) GetPositionList() (*PositionList, error) {
+	return ps.GetPositionListContext(context.Background())
+}
+
+// GetPositionListContext is GetPositionList with a context to cancel the request
+// or to set its deadline.
+func (ps *PositionService) GetPositionListContext(ctx context.Context) (*PositionList, error) {
+	ep := ps.getEndpoint(endpoint.Position.PositionList)
+	url := fmt.Sprintf(ep, ps.client.accountID)
+	result := &PositionList{}
+	if err := ps.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	fmt.Println(string(data))
+	return result, nil
 }
 
 // GetOpenPositionList is to list all open Positions for an Account.
 // An open Position is a Position in an Account that currently has a
 // Trade opened for it.
-func (ps *position) GetOpenPositionList(live bool, accountID string) {
-	ep := endpoint.GetEndpoint(live, endpoint.Position.OpenPositionList)
-	ps.endpoint = fmt.Sprintf(ep, accountID)
-	fmt.Println(ps.endpoint)
-	ps.method = http.MethodGet
-	data, err := ps.connect()
-	if err != nil {
-		log.Fatal(err)
+func (ps *PositionService) GetOpenPositionList() (*PositionList, error) {
+	return ps.GetOpenPositionListContext(context.Background())
+}
+
+// GetOpenPositionListContext is GetOpenPositionList with a context to cancel the request
+// or to set its deadline.
+func (ps *PositionService) GetOpenPositionListContext(ctx context.Context) (*PositionList, error) {
+	ep := ps.getEndpoint(endpoint.Position.OpenPositionList)
+	url := fmt.Sprintf(ep, ps.client.accountID)
+	result := &PositionList{}
+	if err := ps.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	fmt.Println(string(data))
+	return result, nil
 }
 
 // GetOpenPositionForInstrument is to get the details of a single Instrument’s
 // Position in an Account. The Position may by open or not.
-func (ps *position) GetOpenPositionForInstrument(live bool, accountID, instrument string) {
-	ep := endpoint.GetEndpoint(live, endpoint.Position.SingleInstrumentPosition)
-	ps.endpoint = fmt.Sprintf(ep, accountID, instrument)
-	fmt.Println(ps.endpoint)
-	ps.method = http.MethodGet
-	data, err := ps.connect()
-	if err != nil {
-		log.Fatal(err)
+func (ps *PositionService) GetOpenPositionForInstrument(instrument string) (*PositionDetails, error) {
+	return ps.GetOpenPositionForInstrumentContext(context.Background(), instrument)
+}
+
+// GetOpenPositionForInstrumentContext is GetOpenPositionForInstrument with a context to cancel the request
+// or to set its deadline.
+func (ps *PositionService) GetOpenPositionForInstrumentContext(ctx context.Context, instrument string) (*PositionDetails, error) {
+	ep := ps.getEndpoint(endpoint.Position.SingleInstrumentPosition)
+	url := fmt.Sprintf(ep, ps.client.accountID, instrument)
+	result := &PositionDetails{}
+	if err := ps.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	fmt.Println(string(data))
+	return result, nil
 }
 
 // CloseOpenPositionForInstrument is to closeout the open Position for a
-// specific instrument in an Account.
-func (ps *position) CloseOpenPositionForInstrument(live bool, accountID, instrument string, isLongPosition bool, units interface{}) {
+// specific instrument in an Account. Units is an int, a Decimal or a float64
+// to close a part of the side, or nil to close it fully.
+func (ps *PositionService) CloseOpenPositionForInstrument(instrument string, isLongPosition bool, units interface{}) (*ClosePositionResponse, error) {
+	return ps.CloseOpenPositionForInstrumentContext(context.Background(), instrument, isLongPosition, units)
+}
+
+// CloseOpenPositionForInstrumentContext is CloseOpenPositionForInstrument with a context to cancel the request
+// or to set its deadline.
+func (ps *PositionService) CloseOpenPositionForInstrumentContext(ctx context.Context, instrument string, isLongPosition bool, units interface{}) (*ClosePositionResponse, error) { // {{{
 	var pos string
 	if isLongPosition {
 		pos = "longUnits"
@@ -73,27 +140,30 @@ func (ps *position) CloseOpenPositionForInstrument(live bool, accountID, instrum
 		pos = "shortUnits"
 	}
 	switch t := units.(type) {
-	case int, float64, nil:
-		_, okInt := t.(int)
-		_, okFloat := t.(float64)
-		if okInt && t.(int) < 1 {
-			log.Fatalf("Type INT %v must be greater than 0", t)
-		} else if okFloat && t.(float64) < 1 {
-			log.Fatalf("Type FLOAT64 %v must be greater than 0", t)
-		} else if units == nil {
-			units = "ALL"
+	case int:
+		if t < 1 {
+			return nil, fmt.Errorf("units %v must be greater than 0", t)
+		}
+	case Decimal:
+		if t.Sign() <= 0 {
+			return nil, fmt.Errorf("units %v must be greater than 0", t)
 		}
+	case float64:
+		if t <= 0 {
+			return nil, fmt.Errorf("units %v must be greater than 0", t)
+		}
+		units = DecimalFromFloat(t)
+	case nil:
+		units = "ALL"
 	default:
-		log.Fatal("only accepted types are INT, FLOAT64, NIL")
+		return nil, fmt.Errorf("units %T must be int, Decimal, float64 or nil", units)
 	}
 	body := fmt.Sprintf(`{"%v":"%v"}`, pos, units)
-	ep := endpoint.GetEndpoint(live, endpoint.Position.ClosePositionForInstrument)
-	ps.endpoint = fmt.Sprintf(ep, accountID, instrument)
-	ps.method = http.MethodPut
-	ps.data = []byte(body)
-	data, err := ps.connect()
-	if err != nil {
-		log.Fatal(err)
+	ep := ps.getEndpoint(endpoint.Position.ClosePositionForInstrument)
+	url := fmt.Sprintf(ep, ps.client.accountID, instrument)
+	result := &ClosePositionResponse{}
+	if err := ps.connectJSON(ctx, &request{method: http.MethodPut, endpoint: url, data: []byte(body)}, result); err != nil {
+		return nil, err
 	}
-	fmt.Println(string(data))
-}
+	return result, nil
+} // }}}
@@ -0,0 +1,66 @@
+package gooanda_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+const testPosition = `{"instrument":"EUR_USD","pl":"-0.2000","resettablePL":"-0.2000","financing":"0.0000","commission":"0.0000","unrealizedPL":"1.5000","marginUsed":"4.8800",` +
+	`"long":{"units":"100","averagePrice":"1.22000","tradeIDs":["7"],"pl":"0.0000","resettablePL":"0.0000","financing":"0.0000","unrealizedPL":"1.5000"},` +
+	`"short":{"units":"0","pl":"-0.2000","resettablePL":"-0.2000","financing":"0.0000","unrealizedPL":"0.0000"}}`
+
+func TestGetOpenPositionList(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/openPositions" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"positions":[%v],"lastTransactionID":"7"}`, testPosition)
+	})
+	list, err := client.Positions().GetOpenPositionList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Positions) != 1 {
+		t.Fatalf("positions = %+v", list.Positions)
+	}
+	p := list.Positions[0]
+	if p.Instrument != "EUR_USD" || !p.PL.Equal(dec("-0.2")) || !p.MarginUsed.Equal(dec("4.88")) {
+		t.Errorf("position = %+v", p)
+	}
+	if !p.Long.Units.Equal(dec("100")) || !p.Long.AveragePrice.Equal(dec("1.22")) || len(p.Long.TradeIDs) != 1 || !p.Short.Units.IsZero() {
+		t.Errorf("long = %+v, short = %+v", p.Long, p.Short)
+	}
+}
+
+func TestCloseOpenPositionForInstrument(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v3/accounts/101-001-1-001/positions/EUR_USD/close" {
+			t.Errorf("request = %v %v", r.Method, r.URL.Path)
+		}
+		if body, _ := ioutil.ReadAll(r.Body); string(body) != `{"longUnits":"ALL"}` {
+			t.Errorf("body = %s", body)
+		}
+		fmt.Fprint(w, `{"longOrderCreateTransaction":{"id":"8","type":"MARKET_ORDER","instrument":"EUR_USD","units":"-100","longPositionCloseout":{"instrument":"EUR_USD","units":"ALL"}},`+
+			`"longOrderFillTransaction":{"id":"9","type":"ORDER_FILL","orderID":"8","units":"-100","pl":"1.5000","tradesClosed":[{"tradeID":"7","units":"-100","realizedPL":"1.5000"}]},`+
+			`"relatedTransactionIDs":["8","9"],"lastTransactionID":"9"}`)
+	})
+	resp, err := client.Positions().CloseOpenPositionForInstrument("EUR_USD", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.LongOrderCreateTransaction.LongPositionCloseout.Units != "ALL" {
+		t.Errorf("longOrderCreateTransaction = %+v", resp.LongOrderCreateTransaction)
+	}
+	if fill := resp.LongOrderFillTransaction; !fill.PL.Equal(dec("1.5")) || !fill.TradesClosed[0].RealizedPL.Equal(dec("1.5")) {
+		t.Errorf("longOrderFillTransaction = %+v", fill)
+	}
+	if resp.ShortOrderCreateTransaction != nil {
+		t.Errorf("shortOrderCreateTransaction = %+v", resp.ShortOrderCreateTransaction)
+	}
+
+	if _, err = client.Positions().CloseOpenPositionForInstrument("EUR_USD", true, "100"); err == nil {
+		t.Error("err = nil for string units")
+	}
+}
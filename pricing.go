@@ -1,6 +1,7 @@
 package gooanda
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,127 +12,107 @@ import (
 	"github.com/kokweikhong/gooanda/endpoint"
 )
 
-// GetCandlesLatest data structure
-type pricingCandleLatest struct { // {{{
-	LatestCandles []struct {
-		Instrument  string `json:"instrument"`
-		Granularity string `json:"granularity"`
-		Candles     []struct {
-			Complete bool      `json:"complete"`
-			Volume   float64   `json:"volume"`
-			Time     time.Time `json:"time"`
-			Bid      ohlc      `json:"bid,omitempty"`
-			Ask      ohlc      `json:"ask,omitempty"`
-			Mid      ohlc      `json:"mid,omitempty"`
-		} `json:"candles"`
-	} `json:"latestCandles"`
-} // }}}
+// LatestCandles is the response of GetCandlesLatest.
+type LatestCandles struct {
+	LatestCandles []InstrumentCandles `json:"latestCandles"`
+}
 
-// GetPricingInformation data structure
-type pricingInformation struct { // {{{
-	Time   time.Time `json:"time"`
-	Prices []struct {
-		Type string    `json:"type"`
-		Time time.Time `json:"time"`
-		Bids []struct {
-			Price     string  `json:"price"`
-			Liquidity float64 `json:"liquidity"`
-		} `json:"bids"`
-		Asks []struct {
-			Price     string  `json:"price"`
-			Liquidity float64 `json:"liquidity"`
-		} `json:"asks"`
-		CloseoutBid                string `json:"closeoutBid"`
-		CloseoutAsk                string `json:"closeoutAsk"`
-		Tradeable                  bool   `json:"tradeable"`
-		QuoteHomeConversionFactors struct {
-			PositiveUnits string `json:"positiveUnits"`
-			NegativeUnits string `json:"negativeUnits"`
-		} `json:"quoteHomeConversionFactors"`
-		Instrument string `json:"instrument"`
-	} `json:"prices"`
-} // }}}
+// PricingInformation is the response of GetPricingInformation.
+type PricingInformation struct {
+	Time   Time          `json:"time"`
+	Prices []ClientPrice `json:"prices"`
+}
 
-// GetCandlestickInstrument data structure
-type pricingCandlestickInstrument struct { // {{{
-	Instrument  string `json:"instrument"`
-	Granularity string `json:"granularity"`
-	Candles     []struct {
-		Complete bool      `json:"complete"`
-		Volume   float64   `json:"volume"`
-		Time     time.Time `json:"time"`
-		Mid      ohlc      `json:"mid,omitempty"`
-		Bid      ohlc      `json:"bid,omitempty"`
-		Ask      ohlc      `json:"ask,omitempty"`
-	} `json:"candles"`
+// ClientPrice is the price of an instrument available to an Account.
+type ClientPrice struct { // {{{
+	Type                       string                     `json:"type"`
+	Time                       Time                       `json:"time"`
+	Bids                       []PriceBucket              `json:"bids"`
+	Asks                       []PriceBucket              `json:"asks"`
+	CloseoutBid                Decimal                    `json:"closeoutBid"`
+	CloseoutAsk                Decimal                    `json:"closeoutAsk"`
+	Status                     string                     `json:"status,omitempty"`
+	Tradeable                  bool                       `json:"tradeable"`
+	QuoteHomeConversionFactors QuoteHomeConversionFactors `json:"quoteHomeConversionFactors"`
+	Instrument                 string                     `json:"instrument"`
 } // }}}
 
-type pricingStream struct { // {{{
-	Type string    `json:"type"`
-	Time time.Time `json:"time"`
-	Bids []struct {
-		Price     float64 `json:"price,string"`
-		Liquidity int     `json:"liquidity"`
-	} `json:"bids"`
-	Asks []struct {
-		Price     float64 `json:"price,string"`
-		Liquidity int     `json:"liquidity"`
-	} `json:"asks"`
-	CloseoutBid float64 `json:"closeoutBid,string"`
-	CloseoutAsk float64 `json:"closeoutAsk,string"`
-	Status      string  `json:"status"`
-	Tradeable   bool    `json:"tradeable"`
-	Instrument  string  `json:"instrument"`
-} // }}}
+// PriceBucket is a price available for the amount of liquidity.
+type PriceBucket struct {
+	Price     Decimal `json:"price"`
+	Liquidity float64 `json:"liquidity"`
+}
 
-type ohlc struct {
-	Open  float64 `json:"o,string"`
-	High  float64 `json:"h,string"`
-	Low   float64 `json:"l,string"`
-	Close float64 `json:"c,string"`
+// QuoteHomeConversionFactors is the factors to convert the quote currency
+// of an instrument to the home currency of an Account.
+type QuoteHomeConversionFactors struct {
+	PositiveUnits Decimal `json:"positiveUnits"`
+	NegativeUnits Decimal `json:"negativeUnits"`
 }
 
-type pricing struct {
-	connection
-	Query *pricingFunc
+// PricingHeartbeat is sent by the pricing stream every 5 seconds.
+type PricingHeartbeat struct {
+	Type string `json:"type"`
+	Time Time   `json:"time"`
 }
 
-// NewPricingConnection is to create connection for PRICING API.
-func NewPricingConnection(token string) *pricing {
-	conn := &pricing{}
-	conn.token = token
-	return conn
+// PriceEvent is a message of the pricing stream, Price is set for
+// a PRICE message and Heartbeat for a HEARTBEAT message. A RECONNECT
+// event is sent after the stream is reconnected with Err being the
+// error which dropped the stream.
+type PriceEvent struct {
+	Type      string
+	Price     *ClientPrice
+	Heartbeat *PricingHeartbeat
+	Err       error
 }
 
-func (pr *pricing) connect() ([]byte, error) {
-	con := &connection{pr.endpoint, pr.method, pr.token, pr.data}
-	resp, err := con.connect()
-	if err != nil {
-		return nil, err
+// decodePriceEvent is to decode a message of the pricing stream, the
+// message is decoded once as a heartbeat has the fields type and time
+// of a price.
+func decodePriceEvent(line []byte) (PriceEvent, error) {
+	price := &ClientPrice{}
+	if err := json.Unmarshal(line, price); err != nil {
+		return PriceEvent{}, fmt.Errorf("failed to unmarshal pricing stream message %s, %v", line, err)
+	}
+	event := PriceEvent{Type: price.Type}
+	switch price.Type {
+	case "HEARTBEAT":
+		event.Heartbeat = &PricingHeartbeat{Type: price.Type, Time: price.Time}
+	case "PRICE":
+		event.Price = price
+	default:
+		return event, fmt.Errorf("failed to unmarshal pricing stream message %s, unknown message type %q", line, price.Type)
 	}
-	return resp, nil
+	return event, nil
+}
+
+// PricingService is the PRICING API of Client.Pricing.
+type PricingService struct {
+	connection
+	Query *pricingFunc
 }
 
 // GetCandlesLatest get dancing bears and most recently completed candles
 // within an Account for specified combinations of instrument, granularity,
 // and price component.
-func (pr *pricing) GetCandlesLatest(live bool, accountID string, instruments []string, granularity string, priceComponent string, querys ...pricingOpts) (*pricingCandleLatest, error) { // {{{
+func (pr *PricingService) GetCandlesLatest(instruments []string, granularity string, priceComponent string, querys ...PricingOpts) (*LatestCandles, error) {
+	return pr.GetCandlesLatestContext(context.Background(), instruments, granularity, priceComponent, querys...)
+}
+
+// GetCandlesLatestContext is GetCandlesLatest with a context to cancel the request
+// or to set its deadline.
+func (pr *PricingService) GetCandlesLatestContext(ctx context.Context, instruments []string, granularity string, priceComponent string, querys ...PricingOpts) (*LatestCandles, error) { // {{{
 	querys = append(querys, pr.Query.WithCandleSpecifications(instruments, granularity, priceComponent))
 	q := newPricingQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Pricing.CandleLatest)
-	url := fmt.Sprintf(ep, accountID)
+	ep := pr.getEndpoint(endpoint.Pricing.CandleLatest)
+	url := fmt.Sprintf(ep, pr.client.accountID)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	pr.endpoint = u
-	pr.method = http.MethodGet
-	resp, err := pr.connect()
-	if err != nil {
-		return nil, err
-	}
-	var data = &pricingCandleLatest{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	var data = &LatestCandles{}
+	if err = pr.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
@@ -139,23 +120,23 @@ func (pr *pricing) GetCandlesLatest(live bool, accountID string, instruments []s
 
 // GetPricingInformation is to get pricing information for a specified
 // list of Instruments within an Account.
-func (pr *pricing) GetPricingInformation(live bool, accountID string, instruments []string, querys ...pricingOpts) (*pricingInformation, error) { // {{{
+func (pr *PricingService) GetPricingInformation(instruments []string, querys ...PricingOpts) (*PricingInformation, error) {
+	return pr.GetPricingInformationContext(context.Background(), instruments, querys...)
+}
+
+// GetPricingInformationContext is GetPricingInformation with a context to cancel the request
+// or to set its deadline.
+func (pr *PricingService) GetPricingInformationContext(ctx context.Context, instruments []string, querys ...PricingOpts) (*PricingInformation, error) { // {{{
 	querys = append(querys, pr.Query.WithInstruments(instruments))
 	q := newPricingQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Pricing.PricingInfo)
-	url := fmt.Sprintf(ep, accountID)
+	ep := pr.getEndpoint(endpoint.Pricing.PricingInfo)
+	url := fmt.Sprintf(ep, pr.client.accountID)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	pr.endpoint = u
-	pr.method = http.MethodGet
-	resp, err := pr.connect()
-	if err != nil {
-		return nil, err
-	}
-	var data = &pricingInformation{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	var data = &PricingInformation{}
+	if err = pr.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
@@ -174,19 +155,26 @@ func (pr *pricing) GetPricingInformation(live bool, accountID string, instrument
 // This means that during periods of rapid price movement, different
 // subscribers may observe different prices depending on their alignment.
 // Note: This endpoint is served by the streaming URLs.
-func (pr *pricing) GetStreamingPrice(live bool, accountID string, instruments []string, querys ...pricingOpts) (*pricingStream, error) { // {{{
+//
+// Deprecated: GetStreamingPrice only returns the first message of the stream,
+// use StreamPrices to keep the stream open.
+func (pr *PricingService) GetStreamingPrice(instruments []string, querys ...PricingOpts) (*ClientPrice, error) {
+	return pr.GetStreamingPriceContext(context.Background(), instruments, querys...)
+}
+
+// GetStreamingPriceContext is GetStreamingPrice with a context to cancel the request
+// or to set its deadline.
+func (pr *PricingService) GetStreamingPriceContext(ctx context.Context, instruments []string, querys ...PricingOpts) (*ClientPrice, error) { // {{{
 	querys = append(querys, pr.Query.WithInstruments(instruments))
 	q := newPricingQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Pricing.PricingStream)
-	url := fmt.Sprintf(ep, accountID)
+	ep := pr.getEndpoint(endpoint.Pricing.PricingStream)
+	url := fmt.Sprintf(ep, pr.client.accountID)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	pr.endpoint = u
-	pr.method = http.MethodGet
-	resp, err := pr.connect()
-	result := &pricingStream{}
+	resp, err := pr.connect(ctx, &request{method: http.MethodGet, endpoint: u})
+	result := &ClientPrice{}
 	if err != nil {
 		return result, err
 	}
@@ -196,24 +184,71 @@ func (pr *pricing) GetStreamingPrice(live bool, accountID string, instruments []
 	return result, nil
 } // }}}
 
+// StreamPrices is to get a stream of Account Prices starting from when the
+// request is made, see GetStreamingPrice for the details of the stream.
+// The stream stays open until ctx is done and is reconnected when dropped,
+// every PRICE and HEARTBEAT message is sent as an event. Errors are sent
+// on the error channel which must be received along with the events, both
+// channels are closed when the stream ends.
+func (pr *PricingService) StreamPrices(ctx context.Context, instruments []string, querys ...PricingOpts) (<-chan PriceEvent, <-chan error) { // {{{
+	events := make(chan PriceEvent)
+	errs := make(chan error)
+	querys = append(querys, pr.Query.WithInstruments(instruments))
+	q := newPricingQuery(querys...)
+	ep := pr.getEndpoint(endpoint.Pricing.PricingStream)
+	url := fmt.Sprintf(ep, pr.client.accountID)
+	send := func(event PriceEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(errs)
+		defer close(events)
+		u, err := urlAddQuery(url, q)
+		if err != nil {
+			sendError(ctx, errs, err)
+			return
+		}
+		err = pr.runStream(ctx, &request{method: http.MethodGet, endpoint: u}, streamHandler{
+			message: func(line []byte) {
+				event, err := decodePriceEvent(line)
+				if err != nil {
+					sendError(ctx, errs, err)
+					return
+				}
+				send(event)
+			},
+			reconnected: func(err error) error {
+				send(PriceEvent{Type: StreamReconnect, Err: err})
+				return nil
+			},
+		})
+		if err != nil {
+			sendError(ctx, errs, err)
+		}
+	}()
+	return events, errs
+} // }}}
+
 // GetCandlestickInstrument fetch candlestick data for an instrument.
-func (pr *pricing) GetCandlestickInstrument(live bool, accountID string, instrument string, querys ...pricingOpts) (*pricingCandlestickInstrument, error) { // {{{
+func (pr *PricingService) GetCandlestickInstrument(instrument string, querys ...PricingOpts) (*InstrumentCandles, error) {
+	return pr.GetCandlestickInstrumentContext(context.Background(), instrument, querys...)
+}
+
+// GetCandlestickInstrumentContext is GetCandlestickInstrument with a context to cancel the request
+// or to set its deadline.
+func (pr *PricingService) GetCandlestickInstrumentContext(ctx context.Context, instrument string, querys ...PricingOpts) (*InstrumentCandles, error) { // {{{
 	q := newPricingQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Pricing.InstrumentCandles)
-	url := fmt.Sprintf(ep, accountID, instrument)
+	ep := pr.getEndpoint(endpoint.Pricing.InstrumentCandles)
+	url := fmt.Sprintf(ep, pr.client.accountID, instrument)
 	u, err := urlAddQuery(url, q)
 	if err != nil {
 		return nil, err
 	}
-	pr.endpoint = u
-	pr.method = http.MethodGet
-	resp, err := pr.connect()
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-	var data = &pricingCandlestickInstrument{}
-	if err = json.Unmarshal(resp, &data); err != nil {
+	var data = &InstrumentCandles{}
+	if err = pr.connectJSON(ctx, &request{method: http.MethodGet, endpoint: u}, data); err != nil {
 		return nil, err
 	}
 	return data, nil
@@ -238,9 +273,10 @@ type pricingQuery struct {
 	IncludeFirst           string `json:"includeFirst,omitempty"`
 }
 
-type pricingOpts func(*pricingQuery)
+// PricingOpts is an option of the pricing requests.
+type PricingOpts func(*pricingQuery)
 
-func newPricingQuery(querys ...pricingOpts) *pricingQuery {
+func newPricingQuery(querys ...PricingOpts) *pricingQuery {
 	q := &pricingQuery{}
 	for _, query := range querys {
 		query(q)
@@ -251,18 +287,19 @@ func newPricingQuery(querys ...pricingOpts) *pricingQuery {
 type pricingFunc struct{}
 
 // WithCandleSpecifications is to list of candle specifications to get pricing for.
-func (*pricingFunc) WithCandleSpecifications(instruments []string, granularity, priceComponent string) pricingOpts {
+func (*pricingFunc) WithCandleSpecifications(instruments []string, granularity, priceComponent string) PricingOpts {
 	return func(pq *pricingQuery) {
+		specs := make([]string, len(instruments))
 		for k := range instruments {
-			instruments[k] = instruments[k] + ":" + string(granularity) + ":" + string(priceComponent)
+			specs[k] = instruments[k] + ":" + string(granularity) + ":" + string(priceComponent)
 		}
-		pq.CandleSpecifications = strings.Join(instruments, ",")
+		pq.CandleSpecifications = strings.Join(specs, ",")
 	}
 }
 
 // WithFromTo is the start of the time range to fetch candlesticks for and
 // the end of the time range to fetch candlesticks for.
-func (*pricingFunc) WithFromTo(from, to time.Time) pricingOpts {
+func (*pricingFunc) WithFromTo(from, to time.Time) PricingOpts {
 	return func(pq *pricingQuery) {
 		if to.Unix() < from.Unix() || to.Unix() > time.Now().Unix() {
 			pq.From = ""
@@ -279,7 +316,7 @@ func (*pricingFunc) WithFromTo(from, to time.Time) pricingOpts {
 // Only prices and home conversions (if requested) with a time later than
 // this filter (i.e. the price has changed after the since time) will
 // be provided, and are filtered independently.
-func (*pricingFunc) WithSince(since time.Time) pricingOpts {
+func (*pricingFunc) WithSince(since time.Time) PricingOpts {
 	return func(pq *pricingQuery) {
 		if since.Unix() > time.Now().Unix() {
 			pq.Since = time.Now().Format(time.RFC3339)
@@ -292,26 +329,26 @@ func (*pricingFunc) WithSince(since time.Time) pricingOpts {
 
 // WithUnits is the number of units used to calculate the volume-weighted
 // average bid and ask prices in the returned candles. [default=1]
-func (*pricingFunc) WithUnits(units float64) pricingOpts {
+func (*pricingFunc) WithUnits(units Decimal) PricingOpts {
 	return func(pq *pricingQuery) {
-		if units < 1 {
+		if units.Cmp(DecimalFromInt(1)) < 0 {
 			pq.Units = "1"
 			return
 		} else {
-			pq.Units = fmt.Sprintf("%v", units)
+			pq.Units = units.String()
 		}
 	}
 }
 
 // WithInstruments is to list of Instruments to get pricing for.
-func (*pricingFunc) WithInstruments(instruments []string) pricingOpts {
+func (*pricingFunc) WithInstruments(instruments []string) PricingOpts {
 	return func(pq *pricingQuery) {
 		pq.Instruments = strings.Join(instruments, ",")
 	}
 }
 
 // WithPrice is the Price component(s) to get candlestick data for.
-func (*pricingFunc) WithPrice(priceComponent string) pricingOpts {
+func (*pricingFunc) WithPrice(priceComponent string) PricingOpts {
 	return func(pq *pricingQuery) {
 		pq.Price = priceComponent
 	}
@@ -321,7 +358,7 @@ func (*pricingFunc) WithPrice(priceComponent string) pricingOpts {
 // Count should not be specified if both the start and end parameters are
 // provided, as the time range combined with the granularity will determine
 // the number of candlesticks to return. [default=500, maximum=5000]
-func (*pricingFunc) WithCount(count int) pricingOpts {
+func (*pricingFunc) WithCount(count int) PricingOpts {
 	return func(pq *pricingQuery) {
 		if count < 1 || count > 5000 {
 			pq.Count = "500"
@@ -336,18 +373,18 @@ func (*pricingFunc) WithCount(count int) pricingOpts {
 // This flag enables clients to use the timestamp of the last completed
 // candlestick received to poll for future candlesticks but avoid receiving
 // the previous candlestick repeatedly. [default=True]
-func (*pricingFunc) WithoutIncludeFirst() pricingOpts {
+func (*pricingFunc) WithoutIncludeFirst() PricingOpts {
 	return func(pq *pricingQuery) { pq.IncludeFirst = "false" }
 }
 
 // WithGranularity is granularity of the candlesticks to fetch [default=S5]
-func (*pricingFunc) WithGranularity(granularity string) pricingOpts {
+func (*pricingFunc) WithGranularity(granularity string) PricingOpts {
 	return func(pq *pricingQuery) { pq.Granularity = granularity }
 }
 
 // WithoutSnapshot is that enables/disables the sending of a pricing snapshot
 // when initially connecting to the stream. [default=True]
-func (*pricingFunc) WithoutSnapshot() pricingOpts {
+func (*pricingFunc) WithoutSnapshot() PricingOpts {
 	return func(pq *pricingQuery) { pq.Snapshot = "false" }
 }
 
@@ -355,13 +392,13 @@ func (*pricingFunc) WithoutSnapshot() pricingOpts {
 // homeConversions field in the returned response. An entry will be returned
 // for each currency in the set of all base and quote currencies present
 // in the requested instruments list. [default=False]
-func (*pricingFunc) WithIncludeHomeConversions() pricingOpts {
+func (*pricingFunc) WithIncludeHomeConversions() PricingOpts {
 	return func(pq *pricingQuery) { pq.IncludeHomeConversions = "true" }
 }
 
 // WithWeeklyAlignment is a day of the week used for granularities that
 // have weekly alignment. [default=Friday]
-func (*pricingFunc) WithWeeklyAlignment(weeklyAlignment string) pricingOpts {
+func (*pricingFunc) WithWeeklyAlignment(weeklyAlignment string) PricingOpts {
 	return func(pq *pricingQuery) { pq.WeeklyAlignment = weeklyAlignment }
 }
 
@@ -369,13 +406,13 @@ func (*pricingFunc) WithWeeklyAlignment(weeklyAlignment string) pricingOpts {
 // Candlesticks with daily alignment will be aligned to the dailyAlignment
 // hour within the alignmentTimezone. Note that the returned times will still
 // be represented in UTC. [default=America/New_York]
-func (*pricingFunc) WithAlignmentTimezone(timezone string) pricingOpts {
+func (*pricingFunc) WithAlignmentTimezone(timezone string) PricingOpts {
 	return func(pq *pricingQuery) { pq.AlignmentTimezone = timezone }
 }
 
 // WithDailyAlignment is hour of the day (in the specified timezone) to use
 // for granularities that have daily alignments. [default=17, minimum=0, maximum=23]
-func (*pricingFunc) WithDailyAlignment(alignment int) pricingOpts {
+func (*pricingFunc) WithDailyAlignment(alignment int) PricingOpts {
 	return func(pq *pricingQuery) {
 		if alignment < 0 || alignment > 23 {
 			pq.DailyAlignment = ""
@@ -389,6 +426,6 @@ func (*pricingFunc) WithDailyAlignment(alignment int) pricingOpts {
 // or not. A smoothed candlestick uses the previous candle’s close price as
 // its open price, while an unsmoothed candlestick uses the first price from
 // its time range as its open price. [default=False]
-func (*pricingFunc) WithSmooth() pricingOpts {
+func (*pricingFunc) WithSmooth() PricingOpts {
 	return func(pq *pricingQuery) { pq.Smooth = "true" }
 }
@@ -0,0 +1,108 @@
+package gooanda_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+const (
+	testPrice     = `{"type":"PRICE","time":"2021-01-01T00:00:00.000000000Z","bids":[{"price":"1.22000","liquidity":10000000}],"asks":[{"price":"1.22010","liquidity":10000000}],"closeoutBid":"1.21990","closeoutAsk":"1.22020","status":"tradeable","tradeable":true,"instrument":"EUR_USD"}`
+	testHeartbeat = `{"type":"HEARTBEAT","time":"2021-01-01T00:00:05.000000000Z"}`
+)
+
+func TestStreamPrices(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/pricing/stream" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("instruments"); got != "EUR_USD" {
+			t.Errorf("instruments = %v", got)
+		}
+		for _, msg := range []string{testPrice, testHeartbeat, testPrice} {
+			fmt.Fprintln(w, msg)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.Pricing().StreamPrices(ctx, []string{"EUR_USD"})
+	var types []string
+	for len(types) < 3 {
+		select {
+		case event := <-events:
+			types = append(types, event.Type)
+			if event.Type == "PRICE" && (event.Price == nil || !event.Price.Bids[0].Price.Equal(dec("1.22"))) {
+				t.Errorf("price = %+v", event.Price)
+			}
+			if event.Type == "HEARTBEAT" && (event.Heartbeat == nil || event.Heartbeat.Time.Second() != 5) {
+				t.Errorf("heartbeat = %+v", event.Heartbeat)
+			}
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for events")
+		}
+	}
+	if fmt.Sprint(types) != "[PRICE HEARTBEAT PRICE]" {
+		t.Errorf("types = %v", types)
+	}
+
+	cancel()
+	timeout := time.After(5 * time.Second)
+	for events != nil || errs != nil {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+			} else {
+				t.Errorf("error after cancel, %v", err)
+			}
+		case <-timeout:
+			t.Fatal("stream not closed after cancel")
+		}
+	}
+}
+
+func TestStreamPricesClosedByServer(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, testHeartbeat)
+	}, gooanda.WithoutStreamReconnect())
+	events, errs := client.Pricing().StreamPrices(context.Background(), []string{"EUR_USD"})
+	if event := <-events; event.Type != "HEARTBEAT" {
+		t.Errorf("event = %+v", event)
+	}
+	if err := <-errs; err == nil {
+		t.Error("err = nil, want stream closed error")
+	}
+}
+
+func TestGetPricingInformation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"time":"2021-01-01T00:00:00.000000000Z","prices":[%v]}`, strings.Replace(testPrice,
+			`"instrument"`, `"quoteHomeConversionFactors":{"positiveUnits":"1.00000","negativeUnits":"1.00000"},"instrument"`, 1))
+	})
+	info, err := client.Pricing().GetPricingInformation([]string{"EUR_USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var price gooanda.ClientPrice = info.Prices[0]
+	var bucket gooanda.PriceBucket = price.Asks[0]
+	if !bucket.Price.Equal(dec("1.2201")) || bucket.Liquidity != 10000000 || !price.CloseoutBid.Equal(dec("1.2199")) {
+		t.Errorf("price = %+v", price)
+	}
+	if !price.QuoteHomeConversionFactors.PositiveUnits.Equal(dec("1")) {
+		t.Errorf("quoteHomeConversionFactors = %+v", price.QuoteHomeConversionFactors)
+	}
+}
@@ -0,0 +1,199 @@
+package gooanda
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimit is the token bucket settings used to limit the requests sent
+// with the same token, a rate of zero disables the limit.
+type RateLimit struct {
+	Rate  float64 // requests per second
+	Burst int     // requests allowed at once
+}
+
+var (
+	// DefaultRateLimit is the OANDA limit of 120 requests per second per token.
+	DefaultRateLimit = RateLimit{Rate: 120, Burst: 120}
+	// DefaultStreamRateLimit is the OANDA limit of 2 new stream connections per second.
+	DefaultStreamRateLimit = RateLimit{Rate: 2, Burst: 2}
+)
+
+// RateLimitError is returned instead of waiting when the client is created
+// with WithRateLimitError and the limit of the token is reached.
+type RateLimitError struct {
+	Stream     bool
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Stream {
+		return fmt.Sprintf("stream connection rate limit reached, retry after %v", e.RetryAfter)
+	}
+	return fmt.Sprintf("request rate limit reached, retry after %v", e.RetryAfter)
+}
+
+// WithRateLimit is the limit of requests shared by every client created with
+// the same token, the latest client created with this option sets the limit
+// for all of them. The limits of a token are kept until the program exits,
+// even when its clients are no longer used, so a program creating clients
+// for an unbounded number of tokens grows by a few hundred bytes per token.
+// [default=DefaultRateLimit]
+func WithRateLimit(limit RateLimit) ClientOpts {
+	return func(c *Client) { c.rateLimit = &limit }
+}
+
+// WithStreamRateLimit is the limit of new stream connections shared by every
+// client created with the same token. [default=DefaultStreamRateLimit]
+func WithStreamRateLimit(limit RateLimit) ClientOpts {
+	return func(c *Client) { c.streamRateLimit = &limit }
+}
+
+// WithRateLimitError is to return a *RateLimitError when the limit is reached
+// instead of blocking until the request is allowed.
+func WithRateLimitError() ClientOpts {
+	return func(c *Client) { c.rateLimitError = true }
+}
+
+// limiter is a token bucket safe for concurrent use.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	l := &limiter{}
+	l.setLimit(limit)
+	l.tokens = l.burst
+	return l
+}
+
+func (l *limiter) setLimit(limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = limit.Rate
+	l.burst = float64(limit.Burst)
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// advance is to refill the bucket up to now, the lock must be held.
+func (l *limiter) advance(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// reserve is to take a token and get the time to wait before using it,
+// when wait is false no token is taken if it is not available now.
+func (l *limiter) reserve(wait bool) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return 0, true
+	}
+	l.advance(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, true
+	}
+	delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if !wait {
+		return delay, false
+	}
+	l.tokens--
+	return delay, true
+}
+
+// cancel is to give back a reserved token which has not been used.
+func (l *limiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// wait is to block until a token is available or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	delay, _ := l.reserve(true)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenLimiter is the rate limits shared by every client using a token.
+type tokenLimiter struct {
+	rest   *limiter
+	stream *limiter
+}
+
+// tokenLimiters is never pruned as a client does not tell when it is no longer
+// used, see WithRateLimit.
+var (
+	tokenLimitersMu sync.Mutex
+	tokenLimiters   = map[[sha256.Size]byte]*tokenLimiter{}
+)
+
+// sharedLimiter is to get the rate limits of the token, the given limits
+// replace the current ones when not nil.
+func sharedLimiter(token string, rest, stream *RateLimit) *tokenLimiter {
+	key := sha256.Sum256([]byte(token))
+	tokenLimitersMu.Lock()
+	defer tokenLimitersMu.Unlock()
+	tl, ok := tokenLimiters[key]
+	if !ok {
+		tl = &tokenLimiter{
+			rest:   newLimiter(DefaultRateLimit),
+			stream: newLimiter(DefaultStreamRateLimit),
+		}
+		tokenLimiters[key] = tl
+	}
+	if rest != nil {
+		tl.rest.setLimit(*rest)
+	}
+	if stream != nil {
+		tl.stream.setLimit(*stream)
+	}
+	return tl
+}
+
+// waitRateLimit is to wait for the rate limit of the token before
+// sending a request or opening a stream.
+func (c *Client) waitRateLimit(ctx context.Context, stream bool) error {
+	l := c.limiter.rest
+	if stream {
+		l = c.limiter.stream
+	}
+	if c.rateLimitError {
+		if delay, ok := l.reserve(false); !ok {
+			return &RateLimitError{Stream: stream, RetryAfter: delay}
+		}
+		return nil
+	}
+	return l.wait(ctx)
+}
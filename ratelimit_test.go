@@ -0,0 +1,83 @@
+package gooanda_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+func pricingHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, `{"prices":[]}`)
+}
+
+func TestRateLimitSharedByToken(t *testing.T) {
+	env := newTestEnvironment(t, pricingHandler)
+	limit := gooanda.RateLimit{Rate: 20, Burst: 1}
+	clients := []*gooanda.Client{
+		gooanda.NewClient(t.Name(), gooanda.WithEnvironment(env), gooanda.WithRateLimit(limit)),
+		gooanda.NewClient(t.Name(), gooanda.WithEnvironment(env)),
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(client *gooanda.Client) {
+			defer wg.Done()
+			if _, err := client.Pricing().GetPricingInformation([]string{"EUR_USD"}); err != nil {
+				t.Error(err)
+			}
+		}(clients[i%2])
+	}
+	wg.Wait()
+	// the first request uses the burst, the other 9 wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("10 requests took %v, want at least 400ms", elapsed)
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	client := gooanda.NewClient(t.Name(),
+		gooanda.WithEnvironment(newTestEnvironment(t, pricingHandler)),
+		gooanda.WithRateLimit(gooanda.RateLimit{Rate: 1, Burst: 1}),
+		gooanda.WithRateLimitError())
+	if _, err := client.Pricing().GetPricingInformation([]string{"EUR_USD"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.Pricing().GetPricingInformation([]string{"EUR_USD"})
+	var rateLimitErr *gooanda.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("err = %v, want *RateLimitError", err)
+	}
+	if rateLimitErr.Stream || rateLimitErr.RetryAfter <= 0 || rateLimitErr.RetryAfter > time.Second {
+		t.Errorf("rate limit error = %+v", rateLimitErr)
+	}
+}
+
+func TestStreamRateLimit(t *testing.T) {
+	client := gooanda.NewClient(t.Name(),
+		gooanda.WithEnvironment(newTestEnvironment(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"type":"HEARTBEAT","time":"2021-01-01T00:00:00.000000000Z"}`)
+		})),
+		gooanda.WithStreamRateLimit(gooanda.RateLimit{Rate: 1, Burst: 1}),
+		gooanda.WithRateLimitError())
+	if _, err := client.Pricing().GetPricingInformation([]string{"EUR_USD"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Pricing().GetStreamingPrice([]string{"EUR_USD"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.Pricing().GetStreamingPrice([]string{"EUR_USD"})
+	var rateLimitErr *gooanda.RateLimitError
+	if !errors.As(err, &rateLimitErr) || !rateLimitErr.Stream {
+		t.Fatalf("err = %v, want stream *RateLimitError", err)
+	}
+	if _, err := client.Pricing().GetPricingInformation([]string{"EUR_USD"}); err != nil {
+		t.Errorf("rest request limited by stream budget, %v", err)
+	}
+}
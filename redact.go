@@ -0,0 +1,77 @@
+package gooanda
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// redacted replaces the access token in every error, log and debug output.
+const redacted = "[REDACTED]"
+
+// redactedError is an error whose message has the access token replaced,
+// errors.Is and errors.As still see the wrapped error.
+type redactedError struct {
+	err   error
+	token string
+}
+
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.token, redacted)
+}
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redact is to hide the token of the client from err.
+func (c *Client) redact(err error) error {
+	if err == nil || c.token == "" {
+		return err
+	}
+	if apiErr, ok := err.(*APIError); ok {
+		// the reject transaction echoes the request, e.g. its client extensions.
+		apiErr.Body = c.redactBytes(apiErr.Body)
+		apiErr.RejectTransaction = c.redactBytes(apiErr.RejectTransaction)
+		for _, s := range []*string{&apiErr.ErrorCode, &apiErr.ErrorMessage, &apiErr.RejectReason} {
+			*s = strings.ReplaceAll(*s, c.token, redacted)
+		}
+	}
+	if !strings.Contains(err.Error(), c.token) {
+		return err
+	}
+	return &redactedError{err: err, token: c.token}
+}
+
+// redactBytes is to hide the token of the client from a response body,
+// so it cannot be echoed by the errors of the response decoding.
+func (c *Client) redactBytes(b []byte) []byte {
+	if c.token == "" || !bytes.Contains(b, []byte(c.token)) {
+		return b
+	}
+	return bytes.ReplaceAll(b, []byte(c.token), []byte(redacted))
+}
+
+// RedactHeader is to get a copy of the request header safe to log,
+// with the access token of the Authorization header replaced.
+func RedactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	if h.Get("Authorization") != "" {
+		h.Set("Authorization", "Bearer "+redacted)
+	}
+	return h
+}
+
+// String is the client without its access token.
+func (c Client) String() string {
+	return fmt.Sprintf("gooanda.Client{token: %v, environment: %v, accountID: %v}",
+		redacted, c.environment.Name, c.accountID)
+}
+
+// GoString is the client without its access token, for the %#v verb.
+func (c Client) GoString() string { return c.String() }
+
+// String is the client of the service without its access token.
+func (co connection) String() string { return co.client.String() }
+
+// GoString is the client of the service without its access token.
+func (co connection) GoString() string { return co.client.String() }
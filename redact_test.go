@@ -0,0 +1,140 @@
+package gooanda_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+	"github.com/kokweikhong/gooanda/endpoint"
+)
+
+const testSecretToken = "secret-0123456789abcdef"
+
+// echoTokenHandler is to echo the access token in every response.
+func echoTokenHandler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"errorMessage":"bad token %v"}`+"\n", token)
+	}
+}
+
+func TestRedactErrors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	slow := func(w http.ResponseWriter, r *http.Request) { time.Sleep(200 * time.Millisecond) }
+	tests := []struct {
+		name string
+		env  endpoint.Environment
+		opts []gooanda.ClientOpts
+	}{
+		{"unauthorized", newTestEnvironment(t, echoTokenHandler(http.StatusUnauthorized)), nil},
+		{"retries exhausted", newTestEnvironment(t, echoTokenHandler(http.StatusServiceUnavailable)),
+			[]gooanda.ClientOpts{gooanda.WithRetryPolicy(testRetryPolicy)}},
+		{"invalid body", newTestEnvironment(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		}), nil},
+		{"connection refused", endpoint.Custom(closed.URL, closed.URL), nil},
+		{"timeout", newTestEnvironment(t, slow), []gooanda.ClientOpts{gooanda.WithTimeout(50 * time.Millisecond)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs strings.Builder
+			opts := append([]gooanda.ClientOpts{
+				gooanda.WithEnvironment(tt.env),
+				gooanda.WithAccountID("101-001-1-001"),
+				gooanda.WithMiddleware(gooanda.LoggingMiddleware(log.New(&logs, "", 0))),
+			}, tt.opts...)
+			client := gooanda.NewClient(testSecretToken, opts...)
+			calls := []func() (interface{}, error){
+				func() (interface{}, error) { return client.Accounts().GetAccountList() },
+				func() (interface{}, error) { return client.Instruments().GetCandles("EUR_USD") },
+				func() (interface{}, error) { return client.Pricing().GetPricingInformation([]string{"EUR_USD"}) },
+				func() (interface{}, error) { return client.Orders().MarketOrderRequest("EUR_USD", dec("100")) },
+				func() (interface{}, error) { return client.Transactions().GetTransactionById("1") },
+			}
+			for _, call := range calls {
+				result, err := call()
+				// the result and the error are printed the ways a caller could log them.
+				if msg := fmt.Sprintf("%v %+v %#v", result, err, err); strings.Contains(msg, testSecretToken) {
+					t.Errorf("result or error contains the token: %v", msg)
+				}
+				var apiErr *gooanda.APIError
+				if errors.As(err, &apiErr) && strings.Contains(string(apiErr.Body), testSecretToken) {
+					t.Errorf("api error body contains the token: %s", apiErr.Body)
+				}
+			}
+			if strings.Contains(logs.String(), testSecretToken) {
+				t.Errorf("log contains the token: %v", logs.String())
+			}
+		})
+	}
+}
+
+func TestRedactStreamErrors(t *testing.T) {
+	env := newTestEnvironment(t, echoTokenHandler(http.StatusUnauthorized))
+	client := gooanda.NewClient(testSecretToken, gooanda.WithEnvironment(env),
+		gooanda.WithAccountID("101-001-1-001"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, errs := client.Pricing().StreamPrices(ctx, []string{"EUR_USD"})
+	for err := range errs {
+		if strings.Contains(err.Error(), testSecretToken) {
+			t.Errorf("stream error contains the token: %v", err)
+		}
+	}
+	_, errs = client.Transactions().StreamTransactions(ctx)
+	for err := range errs {
+		if strings.Contains(err.Error(), testSecretToken) {
+			t.Errorf("stream error contains the token: %v", err)
+		}
+	}
+}
+
+func TestRedactRejectTransaction(t *testing.T) {
+	env := newTestEnvironment(t, func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"orderRejectTransaction":{"id":"6","type":"MARKET_ORDER_REJECT","rejectReason":"%v",`+
+			`"clientExtensions":{"comment":"%v"}},"errorCode":"%v","lastTransactionID":"6"}`, token, token, token)
+	})
+	client := gooanda.NewClient(testSecretToken, gooanda.WithEnvironment(env),
+		gooanda.WithAccountID("101-001-1-001"))
+	_, err := client.Orders().MarketOrderRequest("EUR_USD", dec("100"))
+	var apiErr *gooanda.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if fields := fmt.Sprintf("%s %v %v %s", apiErr.RejectTransaction, apiErr.RejectReason,
+		apiErr.ErrorCode, apiErr.Body); strings.Contains(fields, testSecretToken) {
+		t.Errorf("api error contains the token: %v", fields)
+	}
+	if !strings.Contains(string(apiErr.RejectTransaction), `"comment":"[REDACTED]"`) {
+		t.Errorf("rejectTransaction = %s", apiErr.RejectTransaction)
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	client := gooanda.NewClient(testSecretToken, gooanda.WithAccountID("101-001-1-001"))
+	for _, v := range []interface{}{client, *client, client.Orders(), client.Pricing()} {
+		for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+			if out := fmt.Sprintf(verb, v); strings.Contains(out, testSecretToken) {
+				t.Errorf("%v of %T contains the token: %v", verb, v, out)
+			}
+		}
+	}
+	header := http.Header{"Authorization": {"Bearer " + testSecretToken}}
+	if out := fmt.Sprint(gooanda.RedactHeader(header)); strings.Contains(out, testSecretToken) {
+		t.Errorf("redacted header contains the token: %v", out)
+	}
+	if header.Get("Authorization") != "Bearer "+testSecretToken {
+		t.Error("RedactHeader modified the header")
+	}
+}
@@ -0,0 +1,139 @@
+package gooanda
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy is the policy used to retry transient failures, i.e. network
+// errors, 429 Too Many Requests and 5xx gateway responses. GET requests are
+// retried by default, other requests only when marked with Idempotent.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first one
+	MinBackoff  time.Duration // backoff before the second attempt
+	MaxBackoff  time.Duration // maximum backoff between two attempts
+	Jitter      float64       // fraction of the backoff randomized, from 0 to 1
+}
+
+// DefaultRetryPolicy is the retry policy of a new client.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  200 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	Jitter:      0.2,
+}
+
+// WithRetryPolicy is the policy used to retry transient failures. [default=DefaultRetryPolicy]
+func WithRetryPolicy(policy RetryPolicy) ClientOpts {
+	return func(c *Client) { c.retryPolicy = policy }
+}
+
+// WithoutRetry is to send every request exactly once.
+func WithoutRetry() ClientOpts {
+	return func(c *Client) { c.retryPolicy = RetryPolicy{MaxAttempts: 1} }
+}
+
+type idempotentKey struct{}
+
+// Idempotent is to mark the requests sent with the returned context as safe
+// to retry, e.g. an order request which can not be filled twice.
+func Idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(ctx context.Context) bool {
+	ok, _ := ctx.Value(idempotentKey{}).(bool)
+	return ok
+}
+
+// attempts is the number of attempts allowed for the request.
+func (p RetryPolicy) attempts(ctx context.Context, method string) int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	if method == http.MethodGet || method == http.MethodHead || isIdempotent(ctx) {
+		return p.MaxAttempts
+	}
+	return 1
+}
+
+// backoff is the time to wait after the given attempt, Retry-After
+// from the response is used when set.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+	wait := p.MinBackoff
+	for i := 1; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if p.Jitter > 0 && wait > 0 {
+		delta := p.Jitter * float64(wait)
+		wait = time.Duration(float64(wait) - delta + rand.Float64()*2*delta)
+	}
+	return wait
+}
+
+// retryAfter is to parse the Retry-After header in seconds or http date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// retryable is to check whether the failed attempt is transient.
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return transportFailure(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// transportFailure is whether err is a failure to connect to the api or of
+// the connection, e.g. a refused dial or a connection closed by the server.
+// The errors of a request which cannot be built or sent fail the same way
+// every attempt and are not retried.
+func transportFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,160 @@
+package gooanda_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+	"github.com/kokweikhong/gooanda/endpoint"
+)
+
+var testRetryPolicy = gooanda.RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  time.Millisecond,
+	MaxBackoff:  10 * time.Millisecond,
+}
+
+// sequenceHandler is to reply the status codes in order, then 200 with body.
+func sequenceHandler(calls *int32, body string, statusCodes ...int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(calls, 1))
+		if n <= len(statusCodes) {
+			if statusCodes[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(statusCodes[n-1])
+			return
+		}
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, sequenceHandler(&calls, `{"accounts":[]}`,
+		http.StatusServiceUnavailable, http.StatusTooManyRequests),
+		gooanda.WithRetryPolicy(testRetryPolicy))
+	if _, err := client.Accounts().GetAccountList(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryGiveUp(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, sequenceHandler(&calls, `{"accounts":[]}`,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable),
+		gooanda.WithRetryPolicy(testRetryPolicy))
+	_, err := client.Accounts().GetAccountList()
+	var apiErr *gooanda.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want 503 *APIError", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryNotFound(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, sequenceHandler(&calls, `{}`, http.StatusNotFound),
+		gooanda.WithRetryPolicy(testRetryPolicy))
+	if _, err := client.Accounts().GetAccountList(); err == nil {
+		t.Fatal("err = nil, want 404")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryOrderOnlyWhenIdempotent(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, sequenceHandler(&calls, `{}`, http.StatusServiceUnavailable),
+		gooanda.WithRetryPolicy(testRetryPolicy))
+	if _, err := client.Orders().MarketOrderRequest("EUR_USD", dec("100")); err == nil {
+		t.Fatal("err = nil, want 503")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	calls = 0
+	ctx := gooanda.Idempotent(context.Background())
+	if _, err := client.Orders().MarketOrderRequestContext(ctx, "EUR_USD", dec("100")); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"accounts":[]}`)
+	}, gooanda.WithRetryPolicy(testRetryPolicy))
+	start := time.Now()
+	if _, err := client.Accounts().GetAccountList(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least 1s", elapsed)
+	}
+}
+
+func TestRetryConnectionClosed(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	var calls int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// the connection is closed once the request is received.
+			http.ReadRequest(bufio.NewReader(conn))
+			atomic.AddInt32(&calls, 1)
+			conn.Close()
+		}
+	}()
+	url := "http://" + listener.Addr().String()
+	client := gooanda.NewClient("token", gooanda.WithEnvironment(endpoint.Custom(url, url)),
+		gooanda.WithRetryPolicy(testRetryPolicy))
+	if _, err := client.Accounts().GetAccountList(); err == nil {
+		t.Fatal("err = nil, want connection closed")
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryInvalidRequest(t *testing.T) {
+	url := "http://[::1]:port"
+	client := gooanda.NewClient("token", gooanda.WithEnvironment(endpoint.Custom(url, url)),
+		gooanda.WithRetryPolicy(gooanda.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.Accounts().GetAccountListContext(ctx)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the invalid url without retry", err)
+	}
+}
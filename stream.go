@@ -0,0 +1,186 @@
+package gooanda
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StreamReconnect is the type of the event sent by a stream after it has
+// been reconnected and its state resynced.
+const StreamReconnect = "RECONNECT"
+
+var (
+	// DefaultStreamHeartbeatTimeout is the time without any message after
+	// which a stream is reconnected, OANDA sends a heartbeat every 5 seconds.
+	DefaultStreamHeartbeatTimeout = 10 * time.Second
+	// DefaultStreamReconnectPolicy is the backoff used to reconnect a stream,
+	// the number of attempts is not limited.
+	DefaultStreamReconnectPolicy = RetryPolicy{
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+		Jitter:     0.2,
+	}
+)
+
+// WithStreamHeartbeatTimeout is the time without any message after which
+// a stream is reconnected, zero disables the watchdog. [default=10s]
+func WithStreamHeartbeatTimeout(timeout time.Duration) ClientOpts {
+	return func(c *Client) { c.heartbeatTimeout = timeout }
+}
+
+// WithStreamReconnect is the backoff used to reconnect a dropped stream,
+// MaxAttempts is the number of consecutive failed connections before the
+// stream ends, zero for no limit. [default=DefaultStreamReconnectPolicy]
+func WithStreamReconnect(policy RetryPolicy) ClientOpts {
+	return func(c *Client) {
+		c.streamReconnect = true
+		c.reconnectPolicy = policy
+	}
+}
+
+// WithoutStreamReconnect is to end a stream as soon as it is dropped.
+func WithoutStreamReconnect() ClientOpts {
+	return func(c *Client) { c.streamReconnect = false }
+}
+
+// streamHandler is the callbacks of a stream.
+type streamHandler struct {
+	// message is called with every message of the stream.
+	message func(line []byte)
+	// reconnected is called when the stream is connected again after being
+	// dropped by err, before reading the messages of the new connection.
+	reconnected func(err error) error
+}
+
+// runStream is to keep the stream open until ctx is done, the stream is
+// reconnected with backoff when it is dropped. It returns nil when ctx is done.
+func (co *connection) runStream(ctx context.Context, r *request, h streamHandler) error {
+	policy := co.client.reconnectPolicy
+	var dropped error
+	for failures := 0; ; {
+		connected := false
+		err := co.readStream(ctx, r, func() error {
+			connected = true
+			if dropped == nil || h.reconnected == nil {
+				return nil
+			}
+			return h.reconnected(dropped)
+		}, h.message)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		if !co.client.streamReconnect || !reconnectable(err) {
+			return err
+		}
+		if connected {
+			dropped = err
+			failures = 0
+		}
+		failures++
+		if policy.MaxAttempts > 0 && failures > policy.MaxAttempts {
+			return err
+		}
+		timer := time.NewTimer(policy.backoff(failures, nil))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// reconnectable is to check whether the stream can be connected again.
+func reconnectable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// readStream is to read the newline delimited messages of a stream until
+// it is closed, ctx is done or no message is received within the heartbeat
+// timeout. It returns nil when ctx is done.
+//
+// connected is called once the response is received. handle is called with
+// every message, which must not be kept after handle returns.
+func (co *connection) readStream(ctx context.Context, r *request, connected func() error, handle func(line []byte)) error { // {{{
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// runStream retries the connection, a single attempt is sent unless
+	// the stream is not reconnected.
+	attempts := 1
+	if !co.client.streamReconnect {
+		attempts = co.client.retryPolicy.attempts(ctx, r.method)
+	}
+	resp, err := co.doAttempts(connCtx, r, attempts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if err = connected(); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	timeout := co.client.heartbeatTimeout
+	var watchdog *time.Timer
+	if timeout > 0 {
+		watchdog = time.AfterFunc(timeout, cancel)
+		defer watchdog.Stop()
+	}
+	reader := bufio.NewReader(resp.Body)
+	var buf []byte
+	for {
+		buf, err = readLine(reader, buf)
+		// the watchdog may fire after a full line is read, the line is
+		// handled before the timeout is reported.
+		timedOut := watchdog != nil && !watchdog.Stop()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if line := bytes.TrimSpace(buf); len(line) > 0 && (err == nil || !timedOut) {
+			handle(co.client.redactBytes(line))
+		}
+		if timedOut {
+			return fmt.Errorf("stream %v received no message for %v", resp.Request.URL.Path, timeout)
+		}
+		if err != nil {
+			return fmt.Errorf("stream %v closed, %w", resp.Request.URL.Path, err)
+		}
+		if watchdog != nil {
+			watchdog.Reset(timeout)
+		}
+	}
+} // }}}
+
+// readLine is to read a line into buf, the line is only valid until the
+// next read as buf is reused.
+func readLine(r *bufio.Reader, buf []byte) ([]byte, error) {
+	buf = buf[:0]
+	for {
+		chunk, err := r.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		if err != bufio.ErrBufferFull {
+			return buf, err
+		}
+	}
+}
+
+// sendError is to send err unless ctx is done.
+func sendError(ctx context.Context, errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	case <-ctx.Done():
+	}
+}
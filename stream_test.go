@@ -0,0 +1,194 @@
+package gooanda_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+var testReconnectPolicy = gooanda.RetryPolicy{
+	MinBackoff: time.Millisecond,
+	MaxBackoff: 10 * time.Millisecond,
+}
+
+func testTransaction(id int) string {
+	return fmt.Sprintf(`{"id":"%d","time":"2021-01-01T00:00:00.000000000Z","accountID":"101-001-1-001","batchID":"%d","type":"ORDER_FILL"}`, id, id)
+}
+
+func TestStreamTransactionsReconnect(t *testing.T) {
+	var connections int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/transactions/sinceid") {
+			if got := r.URL.Query().Get("id"); got != "7" {
+				t.Errorf("sinceid id = %v, want 7", got)
+			}
+			fmt.Fprintf(w, `{"transactions":[%v,%v,%v],"lastTransactionID":"10"}`,
+				testTransaction(8), testTransaction(9), testTransaction(10))
+			return
+		}
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			// the stream is dropped after the first transaction.
+			fmt.Fprintln(w, testTransaction(6))
+			fmt.Fprintln(w, `{"type":"HEARTBEAT","lastTransactionID":"7","time":"2021-01-01T00:00:05.000000000Z"}`)
+		default:
+			fmt.Fprintln(w, testTransaction(10))
+			fmt.Fprintln(w, testTransaction(11))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}, gooanda.WithStreamReconnect(testReconnectPolicy))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.Transactions().StreamTransactions(ctx)
+	var got []string
+	for len(got) < 7 {
+		select {
+		case event := <-events:
+			switch {
+			case event.Transaction != nil:
+				got = append(got, event.Transaction.Base().ID)
+			case event.Type == gooanda.StreamReconnect && event.Err == nil:
+				t.Error("reconnect event without the error which dropped the stream")
+				fallthrough
+			default:
+				got = append(got, event.Type)
+			}
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for events, got %v", got)
+		}
+	}
+	if want := "[6 HEARTBEAT 8 9 10 RECONNECT 11]"; fmt.Sprint(got) != want {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestStreamTransactionsBackfillPages(t *testing.T) {
+	var connections int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/transactions/sinceid") {
+			// the pages hold two transactions up to the last one, 12.
+			id, _ := strconv.Atoi(r.URL.Query().Get("id"))
+			var page []string
+			for i := id + 1; i <= id+2 && i <= 12; i++ {
+				page = append(page, testTransaction(i))
+			}
+			fmt.Fprintf(w, `{"transactions":[%v],"lastTransactionID":"12"}`, strings.Join(page, ","))
+			return
+		}
+		if atomic.AddInt32(&connections, 1) == 1 {
+			fmt.Fprintln(w, `{"type":"HEARTBEAT","lastTransactionID":"7","time":"2021-01-01T00:00:05.000000000Z"}`)
+			return
+		}
+		fmt.Fprintln(w, testTransaction(13))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}, gooanda.WithStreamReconnect(testReconnectPolicy))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.Transactions().StreamTransactions(ctx)
+	var got []string
+	for len(got) < 8 {
+		select {
+		case event := <-events:
+			if event.Transaction != nil {
+				got = append(got, event.Transaction.Base().ID)
+			} else {
+				got = append(got, event.Type)
+			}
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for events, got %v", got)
+		}
+	}
+	if want := "[HEARTBEAT 8 9 10 11 12 RECONNECT 13]"; fmt.Sprint(got) != want {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestStreamPricesHeartbeatTimeout(t *testing.T) {
+	var connections int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, testPrice)
+		w.(http.Flusher).Flush()
+		if atomic.AddInt32(&connections, 1) == 1 {
+			// the stream stalls without any heartbeat.
+			time.Sleep(time.Second)
+			return
+		}
+		<-r.Context().Done()
+	}, gooanda.WithStreamReconnect(testReconnectPolicy),
+		gooanda.WithStreamHeartbeatTimeout(100*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.Pricing().StreamPrices(ctx, []string{"EUR_USD"})
+	var got []string
+	for len(got) < 3 {
+		select {
+		case event := <-events:
+			got = append(got, event.Type)
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for events, got %v", got)
+		}
+	}
+	if want := "[PRICE RECONNECT PRICE]"; fmt.Sprint(got) != want {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestStreamReconnectGiveUp(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}, gooanda.WithStreamReconnect(testReconnectPolicy))
+	events, errs := client.Pricing().StreamPrices(context.Background(), []string{"EUR_USD"})
+	select {
+	case err := <-errs:
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("err = %v, want 401", err)
+		}
+	case <-events:
+		t.Error("event received from unauthorized stream")
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not ended after 401")
+	}
+}
+
+func TestStreamReconnectSingleAttempt(t *testing.T) {
+	var connections int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, gooanda.WithRetryPolicy(testRetryPolicy), gooanda.WithStreamReconnect(gooanda.RetryPolicy{
+		MaxAttempts: 2,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}))
+	_, errs := client.Pricing().StreamPrices(context.Background(), []string{"EUR_USD"})
+	select {
+	case err := <-errs:
+		if err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("err = %v, want 503", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not ended after 503")
+	}
+	// the reconnections are not retried again by the retry policy.
+	if got := atomic.LoadInt32(&connections); got != 3 {
+		t.Errorf("connections = %v, want 3", got)
+	}
+}
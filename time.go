@@ -0,0 +1,121 @@
+package gooanda
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kokweikhong/gooanda/kw"
+)
+
+// Time is a DateTime of the api, it is decoded from either the RFC 3339
+// format or the UNIX format of seconds with a fraction, so the responses
+// are decoded the same way whatever the datetime format of the client.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON is to decode an RFC 3339 or a UNIX DateTime.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := parseTime(string(data))
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalJSON is to encode the time in the RFC 3339 format.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(t.UTC().Format(time.RFC3339Nano))), nil
+}
+
+// parseTime is to parse an RFC 3339 or a UNIX DateTime.
+func parseTime(value string) (time.Time, error) {
+	if strings.ContainsAny(value, "T:") {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse RFC3339 time %q, %v", value, err)
+		}
+		return parsed, nil
+	}
+	seconds, fraction := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		seconds, fraction = value[:i], value[i+1:]
+	}
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || len(fraction) > 9 {
+		return time.Time{}, fmt.Errorf("failed to parse UNIX time %q", value)
+	}
+	var nsec int64
+	if fraction != "" {
+		nsec, err = strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse UNIX time %q", value)
+		}
+	}
+	if strings.HasPrefix(seconds, "-") {
+		nsec = -nsec
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+// formatUnix is to format t in the UNIX DateTime format.
+func formatUnix(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// WithDatetimeFormat is the format of the DateTime fields sent by the api,
+// kw.DATETIMEFORMAT.RFC3339 or kw.DATETIMEFORMAT.UNIX. The responses are
+// decoded into Time whatever the format, the time query options are sent
+// in the format of the client but the gtdTime of the order options must
+// be given in it. [default=RFC3339]
+func WithDatetimeFormat(format string) ClientOpts {
+	return func(c *Client) { c.datetimeFormat = format }
+}
+
+// timeQueryKeys is the query parameters holding a DateTime.
+var timeQueryKeys = []string{"from", "to", "time", "since"}
+
+// unixQueryTimes is to convert the DateTime query parameters of u to the
+// UNIX format, as the format of the request must match the header.
+func unixQueryTimes(u *url.URL) {
+	q := u.Query()
+	changed := false
+	for _, key := range timeQueryKeys {
+		value := q.Get(key)
+		if value == "" {
+			continue
+		}
+		if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			q.Set(key, formatUnix(parsed))
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+}
+
+// setDatetimeFormat is to set the datetime format of the client on req.
+func (c *Client) setDatetimeFormat(req *http.Request) {
+	if c.datetimeFormat == "" {
+		return
+	}
+	req.Header.Set("Accept-Datetime-Format", c.datetimeFormat)
+	if c.datetimeFormat == kw.DATETIMEFORMAT.UNIX {
+		unixQueryTimes(req.URL)
+	}
+}
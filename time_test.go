@@ -0,0 +1,79 @@
+package gooanda_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+	"github.com/kokweikhong/gooanda/kw"
+)
+
+func TestTimeUnmarshalJSON(t *testing.T) {
+	want := time.Date(2021, 6, 1, 8, 0, 0, 123456789, time.UTC)
+	tests := []struct {
+		data string
+		want time.Time
+	}{
+		{`"2021-06-01T08:00:00.123456789Z"`, want},
+		{`"2021-06-01T10:00:00.123456789+02:00"`, want},
+		{`"1622534400.123456789"`, want},
+		{`"1622534400.123456"`, want.Truncate(time.Microsecond)},
+		{`"1622534400"`, want.Truncate(time.Second)},
+		{`1622534400.123456789`, want},
+		{`null`, time.Time{}},
+		{`""`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var got gooanda.Time
+		if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("unmarshal %v, %v", tt.data, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("unmarshal %v = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+	for _, data := range []string{`"yesterday"`, `"1622534400.1234567891"`, `"2021-06-01T08:00"`} {
+		var got gooanda.Time
+		if err := json.Unmarshal([]byte(data), &got); err == nil {
+			t.Errorf("unmarshal %v = %v, want an error", data, got)
+		}
+	}
+}
+
+func TestTimeMarshalJSON(t *testing.T) {
+	in := gooanda.Time{Time: time.Date(2021, 6, 1, 8, 0, 0, 5, time.UTC)}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out gooanda.Time
+	if err = json.Unmarshal(data, &out); err != nil || !out.Equal(in.Time) {
+		t.Errorf("round trip %s = %v, %v", data, out, err)
+	}
+}
+
+func TestDatetimeFormatUnix(t *testing.T) {
+	from := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Datetime-Format"); got != "UNIX" {
+			t.Errorf("Accept-Datetime-Format = %q", got)
+		}
+		if got := r.URL.Query().Get("from"); got != "1622534400.000000000" {
+			t.Errorf("from = %q", got)
+		}
+		fmt.Fprint(w, `{"instrument":"EUR_USD","granularity":"H1","candles":[
+			{"complete":true,"volume":10,"time":"1622534400.000000000","mid":{"o":"1.2","h":"1.3","l":"1.1","c":"1.2"}}]}`)
+	}, gooanda.WithDatetimeFormat(kw.DATETIMEFORMAT.UNIX))
+	in := client.Instruments()
+	candles, err := in.GetCandles("EUR_USD", in.Query.WithFrom(from))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles.Candles) != 1 || !candles.Candles[0].Time.Equal(from) {
+		t.Errorf("candles = %+v", candles.Candles)
+	}
+}
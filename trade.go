@@ -1,179 +1,266 @@
 package gooanda
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 
 	"github.com/kokweikhong/gooanda/endpoint"
 )
 
-// GetTradeList and GetOpenTradeList data structure.
-type tradeList struct {
-	LastTransactionID string      `json:"lastTransactionID"`
-	Trades            []dataTrade `json:"trades"`
+// TradeList is the response of GetTradeList and GetOpenTradeList.
+type TradeList struct {
+	LastTransactionID string  `json:"lastTransactionID"`
+	Trades            []Trade `json:"trades"`
 }
 
-type specificTrade struct {
-	LastTransactionID string    `json:"lastTransactionID"`
-	Trade             dataTrade `json:"trade"`
+// TradeDetails is the response of GetSpecificTradeDetails.
+type TradeDetails struct {
+	LastTransactionID string `json:"lastTransactionID"`
+	Trade             Trade  `json:"trade"`
 }
 
-type dataTrade struct {
-	CurrentUnits float64 `json:"currentUnits,string"`
-	Financing    float64 `json:"financing,string"`
-	Id           string  `json:"id"`
-	InitialUnits float64 `json:"initialUnits,string"`
-	Instrument   string  `json:"instrument"`
-	OpenTime     string  `json:"openTime"`
-	Price        float64 `json:"price,string"`
-	RealizePL    float64 `json:"realizedPL,string"`
-	State        string  `json:"state"`
-	UnrealizePL  float64 `json:"unrealizedPL,string"`
+// Trade is a trade of an Account, the dependent orders are set
+// while they are pending.
+type Trade struct { // {{{
+	ID                      string                   `json:"id"`
+	Instrument              string                   `json:"instrument"`
+	Price                   Decimal                  `json:"price"`
+	OpenTime                Time                     `json:"openTime"`
+	State                   string                   `json:"state"`
+	InitialUnits            Decimal                  `json:"initialUnits"`
+	InitialMarginRequired   Decimal                  `json:"initialMarginRequired"`
+	CurrentUnits            Decimal                  `json:"currentUnits"`
+	RealizedPL              Decimal                  `json:"realizedPL"`
+	UnrealizedPL            Decimal                  `json:"unrealizedPL"`
+	MarginUsed              Decimal                  `json:"marginUsed"`
+	AverageClosePrice       Decimal                  `json:"averageClosePrice"`
+	ClosingTransactionIDs   []string                 `json:"closingTransactionIDs,omitempty"`
+	Financing               Decimal                  `json:"financing"`
+	DividendAdjustment      Decimal                  `json:"dividendAdjustment"`
+	CloseTime               Time                     `json:"closeTime"`
+	ClientExtensions        *ClientExtensions        `json:"clientExtensions,omitempty"`
+	TakeProfitOrder         *TakeProfitOrder         `json:"takeProfitOrder,omitempty"`
+	StopLossOrder           *StopLossOrder           `json:"stopLossOrder,omitempty"`
+	TrailingStopLossOrder   *TrailingStopLossOrder   `json:"trailingStopLossOrder,omitempty"`
+	GuaranteedStopLossOrder *GuaranteedStopLossOrder `json:"guaranteedStopLossOrder,omitempty"`
+} // }}}
+
+// TradeSummary is a Trade with the IDs of its dependent orders
+// instead of the orders.
+type TradeSummary struct { // {{{
+	ID                        string            `json:"id"`
+	Instrument                string            `json:"instrument"`
+	Price                     Decimal           `json:"price"`
+	OpenTime                  Time              `json:"openTime"`
+	State                     string            `json:"state"`
+	InitialUnits              Decimal           `json:"initialUnits"`
+	InitialMarginRequired     Decimal           `json:"initialMarginRequired"`
+	CurrentUnits              Decimal           `json:"currentUnits"`
+	RealizedPL                Decimal           `json:"realizedPL"`
+	UnrealizedPL              Decimal           `json:"unrealizedPL"`
+	MarginUsed                Decimal           `json:"marginUsed"`
+	AverageClosePrice         Decimal           `json:"averageClosePrice"`
+	ClosingTransactionIDs     []string          `json:"closingTransactionIDs,omitempty"`
+	Financing                 Decimal           `json:"financing"`
+	DividendAdjustment        Decimal           `json:"dividendAdjustment"`
+	CloseTime                 Time              `json:"closeTime"`
+	ClientExtensions          *ClientExtensions `json:"clientExtensions,omitempty"`
+	TakeProfitOrderID         string            `json:"takeProfitOrderID,omitempty"`
+	StopLossOrderID           string            `json:"stopLossOrderID,omitempty"`
+	TrailingStopLossOrderID   string            `json:"trailingStopLossOrderID,omitempty"`
+	GuaranteedStopLossOrderID string            `json:"guaranteedStopLossOrderID,omitempty"`
+} // }}}
+
+// TradeCloseResponse is the response of CloseTrade, the trade is closed
+// by a MarketOrder which is filled or cancelled.
+type TradeCloseResponse struct {
+	OrderCreateTransaction *MarketOrderTransaction `json:"orderCreateTransaction"`
+	OrderFillTransaction   *OrderFillTransaction   `json:"orderFillTransaction,omitempty"`
+	OrderCancelTransaction *OrderCancelTransaction `json:"orderCancelTransaction,omitempty"`
+	RelatedTransactionIDs  []string                `json:"relatedTransactionIDs"`
+	LastTransactionID      string                  `json:"lastTransactionID"`
 }
 
-type trade struct {
+// TradeOrdersResponse is the response of UpdateTPSLForTrade, only the
+// transactions of the dependent orders updated are set. A dependent order
+// replaced is cancelled by its OrderCancelTransaction, and a new order
+// filled or cancelled immediately sets its OrderFillTransaction or
+// OrderCreatedCancelTransaction.
+type TradeOrdersResponse struct { // {{{
+	TakeProfitOrderCancelTransaction         *OrderCancelTransaction             `json:"takeProfitOrderCancelTransaction,omitempty"`
+	TakeProfitOrderTransaction               *TakeProfitOrderTransaction         `json:"takeProfitOrderTransaction,omitempty"`
+	TakeProfitOrderFillTransaction           *OrderFillTransaction               `json:"takeProfitOrderFillTransaction,omitempty"`
+	TakeProfitOrderCreatedCancelTransaction  *OrderCancelTransaction             `json:"takeProfitOrderCreatedCancelTransaction,omitempty"`
+	StopLossOrderCancelTransaction           *OrderCancelTransaction             `json:"stopLossOrderCancelTransaction,omitempty"`
+	StopLossOrderTransaction                 *StopLossOrderTransaction           `json:"stopLossOrderTransaction,omitempty"`
+	StopLossOrderFillTransaction             *OrderFillTransaction               `json:"stopLossOrderFillTransaction,omitempty"`
+	StopLossOrderCreatedCancelTransaction    *OrderCancelTransaction             `json:"stopLossOrderCreatedCancelTransaction,omitempty"`
+	TrailingStopLossOrderCancelTransaction   *OrderCancelTransaction             `json:"trailingStopLossOrderCancelTransaction,omitempty"`
+	TrailingStopLossOrderTransaction         *TrailingStopLossOrderTransaction   `json:"trailingStopLossOrderTransaction,omitempty"`
+	GuaranteedStopLossOrderCancelTransaction *OrderCancelTransaction             `json:"guaranteedStopLossOrderCancelTransaction,omitempty"`
+	GuaranteedStopLossOrderTransaction       *GuaranteedStopLossOrderTransaction `json:"guaranteedStopLossOrderTransaction,omitempty"`
+	RelatedTransactionIDs                    []string                            `json:"relatedTransactionIDs"`
+	LastTransactionID                        string                              `json:"lastTransactionID"`
+} // }}}
+
+// TradeService is the TRADE API of Client.Trades.
+type TradeService struct {
 	connection
 	Query             *tradeFunc
-	TakeProftStopLoss *requestTPSL
+	TakeProftStopLoss *tpslFunc
 }
 
-// NewTradeConnection is to crete connection for TRADE API.
-func NewTradeConnection(token string) *trade {
-	con := &trade{}
-	con.connection.token = token
-	con.TakeProftStopLoss = &requestTPSL{}
-	return con
+// GetTradeList is to get a list of Trades for an Account.
+func (tr *TradeService) GetTradeList(opts ...TradeOpts) (*TradeList, error) {
+	return tr.GetTradeListContext(context.Background(), opts...)
 }
 
-// GetTradeList is to get a list of Trades for an Account.
-func (tr *trade) GetTradeList(live bool, accountID string, opts ...tradeOpts) (*tradeList, error) { // {{{
-	var result *tradeList
+// GetTradeListContext is GetTradeList with a context to cancel the request
+// or to set its deadline.
+func (tr *TradeService) GetTradeListContext(ctx context.Context, opts ...TradeOpts) (*TradeList, error) { // {{{
+	result := &TradeList{}
 	query := newTradeQuery(opts...)
-	ep := fmt.Sprintf(endpoint.GetEndpoint(live, endpoint.Trade.Trades), accountID)
+	ep := fmt.Sprintf(tr.getEndpoint(endpoint.Trade.Trades), tr.client.accountID)
 	url, err := urlAddQuery(ep, query)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
-	tr.method = http.MethodGet
-	tr.endpoint = url
-	resp, err := tr.connect()
-	if err != nil {
-		return result, err
-	}
-	fmt.Println(string(resp))
-	if err = json.Unmarshal(resp, &result); err != nil {
-		return result, fmt.Errorf("failed to unmarshal data in GetTradeList, %v", err)
+	if err = tr.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
 	return result, nil
 } // }}}
 
 // GetOpenTradeList is to get the list of open Trades for an Account.
-func (tr *trade) GetOpenTradeList(live bool, accountID string) (*tradeList, error) { // {{{
-	var result *tradeList
-	ep := fmt.Sprintf(endpoint.GetEndpoint(live, endpoint.Trade.OpenTrades), accountID)
-	tr.method = http.MethodGet
-	tr.endpoint = ep
-	resp, err := tr.connect()
-	if err != nil {
-		return result, fmt.Errorf("GetOpenTradeList connect error, %v", err)
-	}
-	if err = json.Unmarshal(resp, &result); err != nil {
-		return result, fmt.Errorf("GetOpenTradeList unmarshal error, %v", err)
+func (tr *TradeService) GetOpenTradeList() (*TradeList, error) {
+	return tr.GetOpenTradeListContext(context.Background())
+}
+
+// GetOpenTradeListContext is GetOpenTradeList with a context to cancel the request
+// or to set its deadline.
+func (tr *TradeService) GetOpenTradeListContext(ctx context.Context) (*TradeList, error) { // {{{
+	result := &TradeList{}
+	ep := fmt.Sprintf(tr.getEndpoint(endpoint.Trade.OpenTrades), tr.client.accountID)
+	if err := tr.connectJSON(ctx, &request{method: http.MethodGet, endpoint: ep}, result); err != nil {
+		return nil, err
 	}
 	return result, nil
 } // }}}
 
 // GetSpecificTradeDetails is to get the details of a specific Trade in an Account.
-func (tr *trade) GetSpecificTradeDetails(live bool, accountID, tradeID string) (*specificTrade, error) { // {{{
-	var result *specificTrade
-	ep := fmt.Sprintf(endpoint.GetEndpoint(live, endpoint.Trade.TradeDetails),
-		accountID, tradeID)
-	tr.method = http.MethodGet
-	tr.endpoint = ep
-	resp, err := tr.connect()
-	if err != nil {
-		return result, fmt.Errorf("GetSpecificTradeDetails connect error, %v", err)
-	}
-	if err = json.Unmarshal(resp, &result); err != nil {
-		return result, fmt.Errorf("GetSpecificTradeDetails unmarshal error, %v", err)
+func (tr *TradeService) GetSpecificTradeDetails(tradeID string) (*TradeDetails, error) {
+	return tr.GetSpecificTradeDetailsContext(context.Background(), tradeID)
+}
+
+// GetSpecificTradeDetailsContext is GetSpecificTradeDetails with a context to cancel the request
+// or to set its deadline.
+func (tr *TradeService) GetSpecificTradeDetailsContext(ctx context.Context, tradeID string) (*TradeDetails, error) { // {{{
+	result := &TradeDetails{}
+	ep := fmt.Sprintf(tr.getEndpoint(endpoint.Trade.TradeDetails),
+		tr.client.accountID, tradeID)
+	if err := tr.connectJSON(ctx, &request{method: http.MethodGet, endpoint: ep}, result); err != nil {
+		return nil, err
 	}
 	return result, nil
 } // }}}
 
 // CloseTrade is to close (partially or fully) a specific open Trade in an Account.
-func (tr *trade) CloseTrade(live bool, accountID, tradeID string, units interface{}) (string, error) { // {{{
-	ep := fmt.Sprintf(endpoint.GetEndpoint(live, endpoint.Trade.CloseTrade),
-		accountID, tradeID)
+// Units is "ALL", or an int, a Decimal or a float64 to close a part of the Trade.
+func (tr *TradeService) CloseTrade(tradeID string, units interface{}) (*TradeCloseResponse, error) {
+	return tr.CloseTradeContext(context.Background(), tradeID, units)
+}
+
+// CloseTradeContext is CloseTrade with a context to cancel the request
+// or to set its deadline.
+func (tr *TradeService) CloseTradeContext(ctx context.Context, tradeID string, units interface{}) (*TradeCloseResponse, error) { // {{{
+	ep := fmt.Sprintf(tr.getEndpoint(endpoint.Trade.CloseTrade),
+		tr.client.accountID, tradeID)
 	switch t := units.(type) {
 	case string:
 		if !strings.EqualFold(t, "all") {
-			return "", fmt.Errorf("units if string must only be all")
+			return nil, fmt.Errorf("units if string must only be all")
 		} else {
 			units = strings.ToUpper(t)
 		}
+	case Decimal:
+		if t.Sign() <= 0 {
+			return nil, fmt.Errorf("%v must be greater than 0", units)
+		}
 	case float64:
 		if t <= 0 {
-			return "", fmt.Errorf("%v must be greater than 0", units)
+			return nil, fmt.Errorf("%v must be greater than 0", units)
 		}
+		units = DecimalFromFloat(t)
 	case int:
 		if t <= 0 {
-			return "", fmt.Errorf("%v must be greater than 0", units)
+			return nil, fmt.Errorf("%v must be greater than 0", units)
 		}
+	default:
+		return nil, fmt.Errorf("units %T must be ALL, int, Decimal or float64", units)
 	}
-	tr.method = http.MethodPut
-	tr.endpoint = ep
-	tr.data = []byte(fmt.Sprintf(`{"units":"%v"}`, units))
-	resp, err := tr.connect()
-	if err != nil {
-		return "", err
+	body := []byte(fmt.Sprintf(`{"units":"%v"}`, units))
+	result := &TradeCloseResponse{}
+	if err := tr.connectJSON(ctx, &request{method: http.MethodPut, endpoint: ep, data: body}, result); err != nil {
+		return nil, err
 	}
-	return string(resp), nil
+	return result, nil
 } // }}}
 
 // UpdateTPSLForTrade is to create, replace and cancel a Trade’s dependent
 // Orders (Take Profit, Stop Loss and Trailing Stop Loss) through the Trade itself
-func (tr *trade) UpdateTPSLForTrade(live bool, accountID, tradeID string) (string, error) { // {{{
-	body, err := json.Marshal(tr.TakeProftStopLoss)
+func (tr *TradeService) UpdateTPSLForTrade(tradeID string, opts ...TPSLOpts) (*TradeOrdersResponse, error) {
+	return tr.UpdateTPSLForTradeContext(context.Background(), tradeID, opts...)
+}
+
+// UpdateTPSLForTradeContext is UpdateTPSLForTrade with a context to cancel the request
+// or to set its deadline.
+func (tr *TradeService) UpdateTPSLForTradeContext(ctx context.Context, tradeID string, opts ...TPSLOpts) (*TradeOrdersResponse, error) { // {{{
+	body, err := json.Marshal(newRequestTPSL(opts...))
 	if err != nil {
-		log.Fatalf("failed to unmarshal takeprofit and stop loss, %v", err)
+		return nil, fmt.Errorf("failed to marshal takeprofit and stop loss, %v", err)
 	}
-	tr.endpoint = fmt.Sprintf(endpoint.GetEndpoint(live,
-		endpoint.Trade.UpdateTrade), accountID, tradeID)
-	tr.data = body
-	tr.method = http.MethodPut
-	resp, err := tr.connect()
-	if err != nil {
-		return "", err
+	url := fmt.Sprintf(tr.getEndpoint(endpoint.Trade.UpdateTrade), tr.client.accountID, tradeID)
+	result := &TradeOrdersResponse{}
+	if err = tr.connectJSON(ctx, &request{method: http.MethodPut, endpoint: url, data: body}, result); err != nil {
+		return nil, err
 	}
-	return string(resp), nil
+	return result, nil
 } // }}}
 
 type requestTPSL struct {
-	TakeProfit         *tpsl `json:"takeProfit,omitempty"`
-	StopLoss           *tpsl `json:"stopLoss,omitempty"`
-	TrailingStopLoss   *tpsl `json:"trailingStopLoss,omitempty"`
-	GuaranteedStopLoss *tpsl `json:"guaranteedStopLoss,omitempty"`
+	TakeProfit         *TakeProfitDetails         `json:"takeProfit,omitempty"`
+	StopLoss           *StopLossDetails           `json:"stopLoss,omitempty"`
+	TrailingStopLoss   *TrailingStopLossDetails   `json:"trailingStopLoss,omitempty"`
+	GuaranteedStopLoss *GuaranteedStopLossDetails `json:"guaranteedStopLoss,omitempty"`
 }
 
-type tpsl struct {
-	Price       float64 `json:"price,omitempty,string"`
-	TimeInForce string  `json:"timeInForce,omitempty"`
-	GtdTime     string  `json:"gtdTime,omitempty"`
-	Distance    float64 `json:"distance,omitempty,string"`
+// TPSLOpts is an option of the dependent orders of a trade.
+type TPSLOpts func(*requestTPSL)
+
+type tpslFunc struct{}
+
+func newRequestTPSL(opts ...TPSLOpts) *requestTPSL {
+	r := &requestTPSL{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // WithTakeProfit specifies the details of a Take Profit Order to be
 // created on behalf of a client. This may happen when an Order is filled
 // that opens a Trade requiring a Take Profit, or when a Trade’s dependent
 // Take Profit Order is modified directly through the Trade.
-func (rtpsl *requestTPSL) WithTakeProfit(price float64, timeInForce, gtdTime string) {
-	rtpsl.TakeProfit = &tpsl{
-		Price:       price,
-		TimeInForce: timeInForce,
-		GtdTime:     gtdTime,
+func (*tpslFunc) WithTakeProfit(price Decimal, timeInForce, gtdTime string) TPSLOpts {
+	return func(rtpsl *requestTPSL) {
+		rtpsl.TakeProfit = &TakeProfitDetails{
+			Price:       price,
+			TimeInForce: timeInForce,
+			GtdTime:     gtdTime,
+		}
 	}
 }
 
@@ -181,11 +268,13 @@ func (rtpsl *requestTPSL) WithTakeProfit(price float64, timeInForce, gtdTime str
 // to be created on behalf of a client. This may happen when an Order
 // is filled that opens a Trade requiring a Stop Loss, or when a Trade’s
 // dependent Stop Loss Order is modified directly through the Trade.
-func (rtpsl *requestTPSL) WithStopLoss(price float64, timeInForce, gtdTime string) {
-	rtpsl.StopLoss = &tpsl{
-		Price:       price,
-		TimeInForce: timeInForce,
-		GtdTime:     gtdTime,
+func (*tpslFunc) WithStopLoss(price Decimal, timeInForce, gtdTime string) TPSLOpts {
+	return func(rtpsl *requestTPSL) {
+		rtpsl.StopLoss = &StopLossDetails{
+			Price:       optionalDecimal(price),
+			TimeInForce: timeInForce,
+			GtdTime:     gtdTime,
+		}
 	}
 }
 
@@ -193,11 +282,13 @@ func (rtpsl *requestTPSL) WithStopLoss(price float64, timeInForce, gtdTime strin
 // to be created on behalf of a client. This may happen when an Order is
 // filled that opens a Trade requiring a Trailing Stop Loss, or when a Trade’s
 // dependent Trailing Stop Loss Order is modified directly through the Trade.
-func (rtpsl *requestTPSL) WithTrailingStopLoss(price float64, timeInForce, gtdTime string) {
-	rtpsl.TrailingStopLoss = &tpsl{
-		Price:       price,
-		TimeInForce: timeInForce,
-		GtdTime:     gtdTime,
+func (*tpslFunc) WithTrailingStopLoss(distance Decimal, timeInForce, gtdTime string) TPSLOpts {
+	return func(rtpsl *requestTPSL) {
+		rtpsl.TrailingStopLoss = &TrailingStopLossDetails{
+			Distance:    distance,
+			TimeInForce: timeInForce,
+			GtdTime:     gtdTime,
+		}
 	}
 }
 
@@ -206,12 +297,14 @@ func (rtpsl *requestTPSL) WithTrailingStopLoss(price float64, timeInForce, gtdTi
 // is filled that opens a Trade requiring a Guaranteed Stop Loss,
 // or when a Trade’s dependent Guaranteed Stop Loss Order is
 // modified directly through the Trade.
-func (rtpsl *requestTPSL) WithGuaranteedStopLoss(price, distance float64, timeInForce, gtdTime string) {
-	rtpsl.GuaranteedStopLoss = &tpsl{
-		Price:       price,
-		TimeInForce: timeInForce,
-		GtdTime:     gtdTime,
-		Distance:    distance,
+func (*tpslFunc) WithGuaranteedStopLoss(price, distance Decimal, timeInForce, gtdTime string) TPSLOpts {
+	return func(rtpsl *requestTPSL) {
+		rtpsl.GuaranteedStopLoss = &GuaranteedStopLossDetails{
+			Price:       optionalDecimal(price),
+			TimeInForce: timeInForce,
+			GtdTime:     gtdTime,
+			Distance:    optionalDecimal(distance),
+		}
 	}
 }
 
@@ -223,11 +316,12 @@ type tradeQuery struct {
 	BeforeID   string `json:"beforeID,omitempty"`
 }
 
-type tradeOpts func(*tradeQuery)
+// TradeOpts is an option of the trade queries.
+type TradeOpts func(*tradeQuery)
 
 type tradeFunc struct{}
 
-func newTradeQuery(opts ...tradeOpts) *tradeQuery {
+func newTradeQuery(opts ...TradeOpts) *tradeQuery {
 	q := &tradeQuery{}
 	for _, query := range opts {
 		query(q)
@@ -236,29 +330,29 @@ func newTradeQuery(opts ...tradeOpts) *tradeQuery {
 }
 
 // WithInstrument is the instrument to filter the requested Trades by.
-func (tf *tradeFunc) WithInstrument(instrument string) tradeOpts {
+func (tf *tradeFunc) WithInstrument(instrument string) TradeOpts {
 	return func(tq *tradeQuery) { tq.Instrument = instrument }
 }
 
 // WithBeforeID is the maximum Trade ID to return. If not provided the
 // most recent Trades in the Account are returned.
-func (tf *tradeFunc) WithBeforeID(beforeID string) tradeOpts {
+func (tf *tradeFunc) WithBeforeID(beforeID string) TradeOpts {
 	return func(tq *tradeQuery) { tq.BeforeID = beforeID }
 }
 
 // WithCount is the maximum number of Trades to return. [default=50, maximum=500]
-func (tf *tradeFunc) WithCount(count int) tradeOpts {
+func (tf *tradeFunc) WithCount(count int) TradeOpts {
 	return func(tq *tradeQuery) { tq.Count = count }
 }
 
 // WithIds is list of Trade IDs to retrieve.
-func (tf *tradeFunc) WithIds(ids []string) tradeOpts {
+func (tf *tradeFunc) WithIds(ids []string) TradeOpts {
 	return func(tq *tradeQuery) {
 		tq.Ids = strings.Join(ids, ",")
 	}
 }
 
 // WithState is the state to filter the requested Trades by. [default=OPEN]
-func (tf *tradeFunc) WithState(state string) tradeOpts {
+func (tf *tradeFunc) WithState(state string) TradeOpts {
 	return func(tq *tradeQuery) { tq.State = state }
 }
@@ -0,0 +1,56 @@
+package gooanda_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetSpecificTradeDetails(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"trade":{"id":"7","instrument":"EUR_USD","price":"1.22000","openTime":"2021-01-01T00:00:01.000000000Z","state":"OPEN","initialUnits":"100","initialMarginRequired":"4.8800",`+
+			`"currentUnits":"100","realizedPL":"0.0000","unrealizedPL":"1.5000","marginUsed":"4.8800","financing":"0.0000",%q:%v},"lastTransactionID":"11"}`,
+			"stopLossOrder", testStopLossOrder)
+	})
+	details, err := client.Trades().GetSpecificTradeDetails("7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := details.Trade
+	if tr.ID != "7" || !tr.UnrealizedPL.Equal(dec("1.5")) || !tr.InitialMarginRequired.Equal(dec("4.88")) || !tr.CloseTime.IsZero() {
+		t.Errorf("trade = %+v", tr)
+	}
+	if tr.StopLossOrder == nil || tr.StopLossOrder.ID != "11" || tr.TakeProfitOrder != nil {
+		t.Errorf("stopLossOrder = %+v, takeProfitOrder = %+v", tr.StopLossOrder, tr.TakeProfitOrder)
+	}
+}
+
+func TestCloseTrade(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v3/accounts/101-001-1-001/trades/7/close" {
+			t.Errorf("request = %v %v", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"orderCreateTransaction":{"id":"12","type":"MARKET_ORDER","instrument":"EUR_USD","units":"-100","tradeClose":{"tradeID":"7","units":"ALL"}},`+
+			`"orderFillTransaction":{"id":"13","type":"ORDER_FILL","orderID":"12","units":"-100","pl":"1.5000","tradesClosed":[{"tradeID":"7","units":"-100","realizedPL":"1.5000"}]},`+
+			`"relatedTransactionIDs":["12","13"],"lastTransactionID":"13"}`)
+	})
+	resp, err := client.Trades().CloseTrade("7", "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.OrderCreateTransaction.TradeClose.TradeID != "7" || !resp.OrderFillTransaction.TradesClosed[0].RealizedPL.Equal(dec("1.5")) {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestCloseTradeInvalidUnits(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request sent for invalid units, %v", r.URL)
+	})
+	d := dec("10")
+	for _, units := range []interface{}{nil, int64(10), &d, "half", dec("-10"), 0} {
+		if _, err := client.Trades().CloseTrade("7", units); err == nil {
+			t.Errorf("units %#v, want an error", units)
+		}
+	}
+}
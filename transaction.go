@@ -1,28 +1,27 @@
 package gooanda
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/kokweikhong/gooanda/endpoint"
+	"github.com/kokweikhong/gooanda/kw"
 )
 
-type transaction struct {
+// TransactionService is the TRANSACTION API of Client.Transactions.
+type TransactionService struct {
 	connection
 	Query *transactionFunc
 }
 
-func NewTransactionConnection(token string) *transaction {
-	conn := &transaction{}
-	conn.token = token
-	return conn
-}
-
-// GetTransactions data structure
-type transactions struct {
+// TransactionPages is the response of GetTransactions, the pages are
+// the urls of the ranges of transactions.
+type TransactionPages struct {
 	From              string   `json:"from"`
 	To                string   `json:"to"`
 	PageSize          int      `json:"pageSize"`
@@ -32,82 +31,813 @@ type transactions struct {
 	LastTransactionID string   `json:"lastTransactionID"`
 }
 
+// Transaction is a transaction of an Account, use a type switch to get
+// the concrete transaction of its type, e.g. *OrderFillTransaction for
+// ORDER_FILL or *DailyFinancingTransaction for DAILY_FINANCING. A transaction
+// of an unknown type is a *BaseTransaction.
+type Transaction interface {
+	Base() *BaseTransaction
+}
+
+// BaseTransaction is the fields common to every transaction type.
+type BaseTransaction struct {
+	ID        string `json:"id"`
+	Time      Time   `json:"time"`
+	UserID    int    `json:"userID"`
+	AccountID string `json:"accountID"`
+	BatchID   string `json:"batchID"`
+	RequestID string `json:"requestID,omitempty"`
+	Type      string `json:"type"`
+}
+
+// Base is the fields common to every transaction type.
+func (t *BaseTransaction) Base() *BaseTransaction { return t }
+
+// MarketOrderTransaction is the transaction creating a MarketOrder.
+type MarketOrderTransaction struct { // {{{
+	BaseTransaction
+	onFill
+	Instrument            string                       `json:"instrument"`
+	Units                 Decimal                      `json:"units"`
+	TimeInForce           string                       `json:"timeInForce"`
+	PriceBound            Decimal                      `json:"priceBound"`
+	PositionFill          string                       `json:"positionFill"`
+	TradeClose            *MarketOrderTradeClose       `json:"tradeClose,omitempty"`
+	LongPositionCloseout  *MarketOrderPositionCloseout `json:"longPositionCloseout,omitempty"`
+	ShortPositionCloseout *MarketOrderPositionCloseout `json:"shortPositionCloseout,omitempty"`
+	MarginCloseout        *MarketOrderMarginCloseout   `json:"marginCloseout,omitempty"`
+	Reason                string                       `json:"reason"`
+	ClientExtensions      *ClientExtensions            `json:"clientExtensions,omitempty"`
+} // }}}
+
+// LimitOrderTransaction is the transaction creating a LimitOrder.
+type LimitOrderTransaction struct {
+	BaseTransaction
+	entryOrder
+	Reason                  string            `json:"reason"`
+	ClientExtensions        *ClientExtensions `json:"clientExtensions,omitempty"`
+	ReplacesOrderID         string            `json:"replacesOrderID,omitempty"`
+	CancellingTransactionID string            `json:"cancellingTransactionID,omitempty"`
+}
+
+// StopOrderTransaction is the transaction creating a StopOrder.
+type StopOrderTransaction struct {
+	BaseTransaction
+	entryOrder
+	PriceBound              Decimal           `json:"priceBound"`
+	Reason                  string            `json:"reason"`
+	ClientExtensions        *ClientExtensions `json:"clientExtensions,omitempty"`
+	ReplacesOrderID         string            `json:"replacesOrderID,omitempty"`
+	CancellingTransactionID string            `json:"cancellingTransactionID,omitempty"`
+}
+
+// MarketIfTouchedOrderTransaction is the transaction creating
+// a MarketIfTouchedOrder.
+type MarketIfTouchedOrderTransaction struct {
+	BaseTransaction
+	entryOrder
+	PriceBound              Decimal           `json:"priceBound"`
+	Reason                  string            `json:"reason"`
+	ClientExtensions        *ClientExtensions `json:"clientExtensions,omitempty"`
+	ReplacesOrderID         string            `json:"replacesOrderID,omitempty"`
+	CancellingTransactionID string            `json:"cancellingTransactionID,omitempty"`
+}
+
+// TakeProfitOrderTransaction is the transaction creating a TakeProfitOrder.
+type TakeProfitOrderTransaction struct {
+	BaseTransaction
+	dependentOrder
+	Reason                  string            `json:"reason"`
+	ClientExtensions        *ClientExtensions `json:"clientExtensions,omitempty"`
+	OrderFillTransactionID  string            `json:"orderFillTransactionID,omitempty"`
+	ReplacesOrderID         string            `json:"replacesOrderID,omitempty"`
+	CancellingTransactionID string            `json:"cancellingTransactionID,omitempty"`
+}
+
+// StopLossOrderTransaction is the transaction creating a StopLossOrder.
+type StopLossOrderTransaction struct {
+	BaseTransaction
+	dependentOrder
+	Distance                Decimal           `json:"distance"`
+	Reason                  string            `json:"reason"`
+	ClientExtensions        *ClientExtensions `json:"clientExtensions,omitempty"`
+	OrderFillTransactionID  string            `json:"orderFillTransactionID,omitempty"`
+	ReplacesOrderID         string            `json:"replacesOrderID,omitempty"`
+	CancellingTransactionID string            `json:"cancellingTransactionID,omitempty"`
+}
+
+// GuaranteedStopLossOrderTransaction is the transaction creating
+// a GuaranteedStopLossOrder.
+type GuaranteedStopLossOrderTransaction struct {
+	BaseTransaction
+	dependentOrder
+	Distance                   Decimal           `json:"distance"`
+	GuaranteedExecutionPremium Decimal           `json:"guaranteedExecutionPremium"`
+	Reason                     string            `json:"reason"`
+	ClientExtensions           *ClientExtensions `json:"clientExtensions,omitempty"`
+	OrderFillTransactionID     string            `json:"orderFillTransactionID,omitempty"`
+	ReplacesOrderID            string            `json:"replacesOrderID,omitempty"`
+	CancellingTransactionID    string            `json:"cancellingTransactionID,omitempty"`
+}
+
+// TrailingStopLossOrderTransaction is the transaction creating
+// a TrailingStopLossOrder.
+type TrailingStopLossOrderTransaction struct {
+	BaseTransaction
+	dependentOrder
+	Distance                Decimal           `json:"distance"`
+	Reason                  string            `json:"reason"`
+	ClientExtensions        *ClientExtensions `json:"clientExtensions,omitempty"`
+	OrderFillTransactionID  string            `json:"orderFillTransactionID,omitempty"`
+	ReplacesOrderID         string            `json:"replacesOrderID,omitempty"`
+	CancellingTransactionID string            `json:"cancellingTransactionID,omitempty"`
+}
+
+// OrderFillTransaction is the transaction of an order filled, the trade
+// opened or the trades closed and reduced by the fill are set.
+type OrderFillTransaction struct { // {{{
+	BaseTransaction
+	OrderID                     string         `json:"orderID"`
+	ClientOrderID               string         `json:"clientOrderID,omitempty"`
+	Instrument                  string         `json:"instrument"`
+	Units                       Decimal        `json:"units"`
+	Price                       Decimal        `json:"price"`
+	FullVWAP                    Decimal        `json:"fullVWAP"`
+	FullPrice                   *ClientPrice   `json:"fullPrice,omitempty"`
+	Reason                      string         `json:"reason"`
+	PL                          Decimal        `json:"pl"`
+	QuotePL                     Decimal        `json:"quotePL"`
+	Financing                   Decimal        `json:"financing"`
+	BaseFinancing               Decimal        `json:"baseFinancing"`
+	QuoteFinancing              Decimal        `json:"quoteFinancing"`
+	Commission                  Decimal        `json:"commission"`
+	GuaranteedExecutionFee      Decimal        `json:"guaranteedExecutionFee"`
+	QuoteGuaranteedExecutionFee Decimal        `json:"quoteGuaranteedExecutionFee"`
+	AccountBalance              Decimal        `json:"accountBalance"`
+	HalfSpreadCost              Decimal        `json:"halfSpreadCost"`
+	TradeOpened                 *TradeOpen     `json:"tradeOpened,omitempty"`
+	TradesClosed                []*TradeReduce `json:"tradesClosed,omitempty"`
+	TradeReduced                *TradeReduce   `json:"tradeReduced,omitempty"`
+} // }}}
+
+// TradeOpen is the trade opened by an OrderFillTransaction.
+type TradeOpen struct {
+	TradeID                string            `json:"tradeID"`
+	Units                  Decimal           `json:"units"`
+	Price                  Decimal           `json:"price"`
+	GuaranteedExecutionFee Decimal           `json:"guaranteedExecutionFee"`
+	HalfSpreadCost         Decimal           `json:"halfSpreadCost"`
+	InitialMarginRequired  Decimal           `json:"initialMarginRequired"`
+	ClientExtensions       *ClientExtensions `json:"clientExtensions,omitempty"`
+}
+
+// TradeReduce is a trade closed or reduced by an OrderFillTransaction.
+type TradeReduce struct {
+	TradeID                string  `json:"tradeID"`
+	Units                  Decimal `json:"units"`
+	Price                  Decimal `json:"price"`
+	RealizedPL             Decimal `json:"realizedPL"`
+	Financing              Decimal `json:"financing"`
+	GuaranteedExecutionFee Decimal `json:"guaranteedExecutionFee"`
+	HalfSpreadCost         Decimal `json:"halfSpreadCost"`
+}
+
+// OrderCancelTransaction is the transaction of an order cancelled.
+type OrderCancelTransaction struct {
+	BaseTransaction
+	OrderID           string `json:"orderID"`
+	ClientOrderID     string `json:"clientOrderID,omitempty"`
+	Reason            string `json:"reason"`
+	ReplacedByOrderID string `json:"replacedByOrderID,omitempty"`
+}
+
+// FixedPriceOrderTransaction is the transaction creating a FixedPriceOrder.
+type FixedPriceOrderTransaction struct {
+	BaseTransaction
+	onFill
+	Instrument       string            `json:"instrument"`
+	Units            Decimal           `json:"units"`
+	Price            Decimal           `json:"price"`
+	PositionFill     string            `json:"positionFill"`
+	TradeState       string            `json:"tradeState"`
+	Reason           string            `json:"reason"`
+	ClientExtensions *ClientExtensions `json:"clientExtensions,omitempty"`
+}
+
+// MarketOrderRejectTransaction is the transaction of a MarketOrder rejected.
+type MarketOrderRejectTransaction struct {
+	MarketOrderTransaction
+	RejectReason string `json:"rejectReason"`
+}
+
+// LimitOrderRejectTransaction is the transaction of a LimitOrder rejected.
+type LimitOrderRejectTransaction struct {
+	LimitOrderTransaction
+	IntendedReplacesOrderID string `json:"intendedReplacesOrderID,omitempty"`
+	RejectReason            string `json:"rejectReason"`
+}
+
+// StopOrderRejectTransaction is the transaction of a StopOrder rejected.
+type StopOrderRejectTransaction struct {
+	StopOrderTransaction
+	IntendedReplacesOrderID string `json:"intendedReplacesOrderID,omitempty"`
+	RejectReason            string `json:"rejectReason"`
+}
+
+// MarketIfTouchedOrderRejectTransaction is the transaction of
+// a MarketIfTouchedOrder rejected.
+type MarketIfTouchedOrderRejectTransaction struct {
+	MarketIfTouchedOrderTransaction
+	IntendedReplacesOrderID string `json:"intendedReplacesOrderID,omitempty"`
+	RejectReason            string `json:"rejectReason"`
+}
+
+// TakeProfitOrderRejectTransaction is the transaction of
+// a TakeProfitOrder rejected.
+type TakeProfitOrderRejectTransaction struct {
+	TakeProfitOrderTransaction
+	IntendedReplacesOrderID string `json:"intendedReplacesOrderID,omitempty"`
+	RejectReason            string `json:"rejectReason"`
+}
+
+// StopLossOrderRejectTransaction is the transaction of
+// a StopLossOrder rejected.
+type StopLossOrderRejectTransaction struct {
+	StopLossOrderTransaction
+	IntendedReplacesOrderID string `json:"intendedReplacesOrderID,omitempty"`
+	RejectReason            string `json:"rejectReason"`
+}
+
+// GuaranteedStopLossOrderRejectTransaction is the transaction of
+// a GuaranteedStopLossOrder rejected.
+type GuaranteedStopLossOrderRejectTransaction struct {
+	GuaranteedStopLossOrderTransaction
+	IntendedReplacesOrderID string `json:"intendedReplacesOrderID,omitempty"`
+	RejectReason            string `json:"rejectReason"`
+}
+
+// TrailingStopLossOrderRejectTransaction is the transaction of
+// a TrailingStopLossOrder rejected.
+type TrailingStopLossOrderRejectTransaction struct {
+	TrailingStopLossOrderTransaction
+	IntendedReplacesOrderID string `json:"intendedReplacesOrderID,omitempty"`
+	RejectReason            string `json:"rejectReason"`
+}
+
+// OrderCancelRejectTransaction is the transaction of an order
+// cancellation rejected.
+type OrderCancelRejectTransaction struct {
+	BaseTransaction
+	OrderID       string `json:"orderID"`
+	ClientOrderID string `json:"clientOrderID,omitempty"`
+	RejectReason  string `json:"rejectReason"`
+}
+
+// OrderClientExtensionsModifyTransaction is the transaction of the client
+// extensions of an order and of the trade it opens modified.
+type OrderClientExtensionsModifyTransaction struct {
+	BaseTransaction
+	OrderID                     string            `json:"orderID"`
+	ClientOrderID               string            `json:"clientOrderID,omitempty"`
+	ClientExtensionsModify      *ClientExtensions `json:"clientExtensionsModify,omitempty"`
+	TradeClientExtensionsModify *ClientExtensions `json:"tradeClientExtensionsModify,omitempty"`
+}
+
+// OrderClientExtensionsModifyRejectTransaction is the transaction of a
+// modification of the client extensions of an order rejected.
+type OrderClientExtensionsModifyRejectTransaction struct {
+	OrderClientExtensionsModifyTransaction
+	RejectReason string `json:"rejectReason"`
+}
+
+// TradeClientExtensionsModifyTransaction is the transaction of the client
+// extensions of a trade modified.
+type TradeClientExtensionsModifyTransaction struct {
+	BaseTransaction
+	TradeID                     string            `json:"tradeID"`
+	ClientTradeID               string            `json:"clientTradeID,omitempty"`
+	TradeClientExtensionsModify *ClientExtensions `json:"tradeClientExtensionsModify,omitempty"`
+}
+
+// TradeClientExtensionsModifyRejectTransaction is the transaction of a
+// modification of the client extensions of a trade rejected.
+type TradeClientExtensionsModifyRejectTransaction struct {
+	TradeClientExtensionsModifyTransaction
+	RejectReason string `json:"rejectReason"`
+}
+
+// CreateTransaction is the transaction of an Account created.
+type CreateTransaction struct {
+	BaseTransaction
+	DivisionID    int    `json:"divisionID"`
+	SiteID        int    `json:"siteID"`
+	AccountUserID int    `json:"accountUserID"`
+	AccountNumber int    `json:"accountNumber"`
+	HomeCurrency  string `json:"homeCurrency"`
+}
+
+// CloseTransaction is the transaction of an Account closed.
+type CloseTransaction struct {
+	BaseTransaction
+}
+
+// ReopenTransaction is the transaction of an Account reopened.
+type ReopenTransaction struct {
+	BaseTransaction
+}
+
+// ClientConfigureTransaction is the transaction of the alias or the margin
+// rate of an Account configured.
+type ClientConfigureTransaction struct {
+	BaseTransaction
+	Alias      string  `json:"alias,omitempty"`
+	MarginRate Decimal `json:"marginRate"`
+}
+
+// ClientConfigureRejectTransaction is the transaction of a configuration
+// of an Account rejected.
+type ClientConfigureRejectTransaction struct {
+	ClientConfigureTransaction
+	RejectReason string `json:"rejectReason"`
+}
+
+// TransferFundsTransaction is the transaction of funds deposited to
+// or withdrawn from an Account, a withdrawal has a negative Amount.
+type TransferFundsTransaction struct {
+	BaseTransaction
+	Amount         Decimal `json:"amount"`
+	FundingReason  string  `json:"fundingReason"`
+	Comment        string  `json:"comment,omitempty"`
+	AccountBalance Decimal `json:"accountBalance"`
+}
+
+// TransferFundsRejectTransaction is the transaction of a transfer
+// of funds rejected.
+type TransferFundsRejectTransaction struct {
+	BaseTransaction
+	Amount        Decimal `json:"amount"`
+	FundingReason string  `json:"fundingReason"`
+	Comment       string  `json:"comment,omitempty"`
+	RejectReason  string  `json:"rejectReason"`
+}
+
+// MarginCallEnterTransaction is the transaction of an Account
+// entering the margin call state.
+type MarginCallEnterTransaction struct {
+	BaseTransaction
+}
+
+// MarginCallExtendTransaction is the transaction of the margin call
+// state of an Account extended.
+type MarginCallExtendTransaction struct {
+	BaseTransaction
+	ExtensionNumber int `json:"extensionNumber"`
+}
+
+// MarginCallExitTransaction is the transaction of an Account
+// leaving the margin call state.
+type MarginCallExitTransaction struct {
+	BaseTransaction
+}
+
+// DelayedTradeClosureTransaction is the transaction of trades of
+// instruments not tradeable to be closed when they are tradeable again,
+// TradeIDs is a comma separated list.
+type DelayedTradeClosureTransaction struct {
+	BaseTransaction
+	Reason   string `json:"reason"`
+	TradeIDs string `json:"tradeIDs"`
+}
+
+// DailyFinancingTransaction is the transaction of the daily financing
+// of the open trades of an Account.
+type DailyFinancingTransaction struct {
+	BaseTransaction
+	Financing            Decimal              `json:"financing"`
+	AccountBalance       Decimal              `json:"accountBalance"`
+	AccountFinancingMode string               `json:"accountFinancingMode,omitempty"`
+	PositionFinancings   []*PositionFinancing `json:"positionFinancings,omitempty"`
+}
+
+// PositionFinancing is the financing of the open trades of a position.
+type PositionFinancing struct {
+	Instrument          string                `json:"instrument"`
+	Financing           Decimal               `json:"financing"`
+	OpenTradeFinancings []*OpenTradeFinancing `json:"openTradeFinancings,omitempty"`
+}
+
+// OpenTradeFinancing is the financing of an open trade.
+type OpenTradeFinancing struct {
+	TradeID   string  `json:"tradeID"`
+	Financing Decimal `json:"financing"`
+}
+
+// DividendAdjustmentTransaction is the transaction of the dividend
+// adjustment of the open trades of an instrument.
+type DividendAdjustmentTransaction struct {
+	BaseTransaction
+	Instrument                   string                         `json:"instrument"`
+	DividendAdjustment           Decimal                        `json:"dividendAdjustment"`
+	QuoteDividendAdjustment      Decimal                        `json:"quoteDividendAdjustment"`
+	AccountBalance               Decimal                        `json:"accountBalance"`
+	OpenTradeDividendAdjustments []*OpenTradeDividendAdjustment `json:"openTradeDividendAdjustments,omitempty"`
+}
+
+// OpenTradeDividendAdjustment is the dividend adjustment of an open trade.
+type OpenTradeDividendAdjustment struct {
+	TradeID                 string  `json:"tradeID"`
+	DividendAdjustment      Decimal `json:"dividendAdjustment"`
+	QuoteDividendAdjustment Decimal `json:"quoteDividendAdjustment"`
+}
+
+// ResetResettablePLTransaction is the transaction of the resettable
+// PL of an Account reset.
+type ResetResettablePLTransaction struct {
+	BaseTransaction
+}
+
+// transactionTypes is the concrete transaction of every transaction type,
+// the One Cancels All transactions are not documented by OANDA thus are
+// decoded into *BaseTransaction as any other unknown type.
+var transactionTypes = map[string]func() Transaction{ // {{{
+	kw.TRANSACTIONFILTER.CREATE:                                func() Transaction { return &CreateTransaction{} },
+	kw.TRANSACTIONFILTER.CLOSE:                                 func() Transaction { return &CloseTransaction{} },
+	kw.TRANSACTIONFILTER.REOPEN:                                func() Transaction { return &ReopenTransaction{} },
+	kw.TRANSACTIONFILTER.CLIENT_CONFIGURE:                      func() Transaction { return &ClientConfigureTransaction{} },
+	kw.TRANSACTIONFILTER.CLIENT_CONFIGURE_REJECT:               func() Transaction { return &ClientConfigureRejectTransaction{} },
+	kw.TRANSACTIONFILTER.TRANSFER_FUNDS:                        func() Transaction { return &TransferFundsTransaction{} },
+	kw.TRANSACTIONFILTER.TRANSFER_FUNDS_REJECT:                 func() Transaction { return &TransferFundsRejectTransaction{} },
+	kw.TRANSACTIONFILTER.MARKET_ORDER:                          func() Transaction { return &MarketOrderTransaction{} },
+	kw.TRANSACTIONFILTER.MARKET_ORDER_REJECT:                   func() Transaction { return &MarketOrderRejectTransaction{} },
+	"FIXED_PRICE_ORDER":                                        func() Transaction { return &FixedPriceOrderTransaction{} },
+	kw.TRANSACTIONFILTER.LIMIT_ORDER:                           func() Transaction { return &LimitOrderTransaction{} },
+	kw.TRANSACTIONFILTER.LIMIT_ORDER_REJECT:                    func() Transaction { return &LimitOrderRejectTransaction{} },
+	kw.TRANSACTIONFILTER.STOP_ORDER:                            func() Transaction { return &StopOrderTransaction{} },
+	kw.TRANSACTIONFILTER.STOP_ORDER_REJECT:                     func() Transaction { return &StopOrderRejectTransaction{} },
+	kw.TRANSACTIONFILTER.MARKET_IF_TOUCHED_ORDER:               func() Transaction { return &MarketIfTouchedOrderTransaction{} },
+	kw.TRANSACTIONFILTER.MARKET_IF_TOUCHED_ORDER_REJECT:        func() Transaction { return &MarketIfTouchedOrderRejectTransaction{} },
+	kw.TRANSACTIONFILTER.TAKE_PROFIT_ORDER:                     func() Transaction { return &TakeProfitOrderTransaction{} },
+	kw.TRANSACTIONFILTER.TAKE_PROFIT_ORDER_REJECT:              func() Transaction { return &TakeProfitOrderRejectTransaction{} },
+	kw.TRANSACTIONFILTER.STOP_LOSS_ORDER:                       func() Transaction { return &StopLossOrderTransaction{} },
+	kw.TRANSACTIONFILTER.STOP_LOSS_ORDER_REJECT:                func() Transaction { return &StopLossOrderRejectTransaction{} },
+	kw.TRANSACTIONFILTER.GUARANTEED_STOP_LOSS_ORDER:            func() Transaction { return &GuaranteedStopLossOrderTransaction{} },
+	kw.TRANSACTIONFILTER.GUARANTEED_STOP_LOSS_ORDER_REJECT:     func() Transaction { return &GuaranteedStopLossOrderRejectTransaction{} },
+	kw.TRANSACTIONFILTER.TRAILING_STOP_LOSS_ORDER:              func() Transaction { return &TrailingStopLossOrderTransaction{} },
+	kw.TRANSACTIONFILTER.TRAILING_STOP_LOSS_ORDER_REJECT:       func() Transaction { return &TrailingStopLossOrderRejectTransaction{} },
+	kw.TRANSACTIONFILTER.ORDER_FILL:                            func() Transaction { return &OrderFillTransaction{} },
+	kw.TRANSACTIONFILTER.ORDER_CANCEL:                          func() Transaction { return &OrderCancelTransaction{} },
+	kw.TRANSACTIONFILTER.ORDER_CANCEL_REJECT:                   func() Transaction { return &OrderCancelRejectTransaction{} },
+	kw.TRANSACTIONFILTER.ORDER_CLIENT_EXTENSIONS_MODIFY:        func() Transaction { return &OrderClientExtensionsModifyTransaction{} },
+	kw.TRANSACTIONFILTER.ORDER_CLIENT_EXTENSIONS_MODIFY_REJECT: func() Transaction { return &OrderClientExtensionsModifyRejectTransaction{} },
+	kw.TRANSACTIONFILTER.TRADE_CLIENT_EXTENSIONS_MODIFY:        func() Transaction { return &TradeClientExtensionsModifyTransaction{} },
+	kw.TRANSACTIONFILTER.TRADE_CLIENT_EXTENSIONS_MODIFY_REJECT: func() Transaction { return &TradeClientExtensionsModifyRejectTransaction{} },
+	kw.TRANSACTIONFILTER.MARGIN_CALL_ENTER:                     func() Transaction { return &MarginCallEnterTransaction{} },
+	kw.TRANSACTIONFILTER.MARGIN_CALL_EXTEND:                    func() Transaction { return &MarginCallExtendTransaction{} },
+	kw.TRANSACTIONFILTER.MARGIN_CALL_EXIT:                      func() Transaction { return &MarginCallExitTransaction{} },
+	kw.TRANSACTIONFILTER.DELAYED_TRADE_CLOSURE:                 func() Transaction { return &DelayedTradeClosureTransaction{} },
+	kw.TRANSACTIONFILTER.DAILY_FINANCING:                       func() Transaction { return &DailyFinancingTransaction{} },
+	"DIVIDEND_ADJUSTMENT":                                      func() Transaction { return &DividendAdjustmentTransaction{} },
+	kw.TRANSACTIONFILTER.RESET_RESETTABLE_PL:                   func() Transaction { return &ResetResettablePLTransaction{} },
+} // }}}
+
+// newTransaction is to create the concrete transaction of the transaction type.
+func newTransaction(transactionType string) Transaction {
+	if create, ok := transactionTypes[transactionType]; ok {
+		return create()
+	}
+	return &BaseTransaction{}
+}
+
+// decodeTransaction is to decode the transaction into the concrete
+// transaction of its type.
+func decodeTransaction(data []byte) (Transaction, error) {
+	var base struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction %s, %v", data, err)
+	}
+	t := newTransaction(base.Type)
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction %s to %T, %v", data, t, err)
+	}
+	return t, nil
+}
+
+// decodeOptionalTransaction is decodeTransaction for a field which may be
+// missing or null, nil is returned then.
+func decodeOptionalTransaction(data json.RawMessage) (Transaction, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	return decodeTransaction(data)
+}
+
+// Transactions is a list of transactions decoded into their concrete
+// transaction types.
+type Transactions []Transaction
+
+func (ts *Transactions) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	transactions := make(Transactions, 0, len(raws))
+	for _, raw := range raws {
+		t, err := decodeTransaction(raw)
+		if err != nil {
+			return err
+		}
+		transactions = append(transactions, t)
+	}
+	*ts = transactions
+	return nil
+}
+
+// TransactionList is the response of GetTransactionRangeById
+// and GetTransactionRange.
+type TransactionList struct {
+	Transactions      Transactions `json:"transactions"`
+	LastTransactionID string       `json:"lastTransactionID"`
+}
+
+// TransactionDetails is the response of GetTransactionById.
+type TransactionDetails struct {
+	Transaction       Transaction `json:"transaction"`
+	LastTransactionID string      `json:"lastTransactionID"`
+}
+
+func (td *TransactionDetails) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Transaction       json.RawMessage `json:"transaction"`
+		LastTransactionID string          `json:"lastTransactionID"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	td.LastTransactionID = raw.LastTransactionID
+	t, err := decodeOptionalTransaction(raw.Transaction)
+	if err != nil {
+		return err
+	}
+	td.Transaction = t
+	return nil
+}
+
+// TransactionHeartbeat is sent by the transaction stream every 5 seconds.
+type TransactionHeartbeat struct {
+	Type              string `json:"type"`
+	LastTransactionID string `json:"lastTransactionID"`
+	Time              Time   `json:"time"`
+}
+
+// TransactionEvent is a message of the transaction stream, Heartbeat is set
+// for a HEARTBEAT message and Transaction, decoded into the concrete
+// transaction of its type, for every other message. A RECONNECT event is
+// sent after the stream is reconnected with Err being the error which
+// dropped the stream.
+type TransactionEvent struct {
+	Type        string
+	Transaction Transaction
+	Heartbeat   *TransactionHeartbeat
+	Err         error
+}
+
+// decodeTransactionEvent is to decode a message of the transaction stream.
+func decodeTransactionEvent(line []byte) (TransactionEvent, error) {
+	var event TransactionEvent
+	var base struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(line, &base); err != nil {
+		return event, fmt.Errorf("failed to unmarshal transaction stream message %s, %v", line, err)
+	}
+	event.Type = base.Type
+	if base.Type == "HEARTBEAT" {
+		event.Heartbeat = &TransactionHeartbeat{}
+		if err := json.Unmarshal(line, event.Heartbeat); err != nil {
+			return event, fmt.Errorf("failed to unmarshal transaction heartbeat %s, %v", line, err)
+		}
+		return event, nil
+	}
+	t := newTransaction(base.Type)
+	if err := json.Unmarshal(line, t); err != nil {
+		return event, fmt.Errorf("failed to unmarshal transaction %s to %T, %v", line, t, err)
+	}
+	event.Transaction = t
+	return event, nil
+}
+
 // GetTransactions is to get a list of Transactions pages
 // that satisfy a time-based Transaction query.
-func (tc *transaction) GetTransactions(live bool, accountID string, querys ...transactionOpts) (*transactions, error) {
-	var result *transactions
+func (tc *TransactionService) GetTransactions(querys ...TransactionOpts) (*TransactionPages, error) {
+	return tc.GetTransactionsContext(context.Background(), querys...)
+}
+
+// GetTransactionsContext is GetTransactions with a context to cancel the request
+// or to set its deadline.
+func (tc *TransactionService) GetTransactionsContext(ctx context.Context, querys ...TransactionOpts) (*TransactionPages, error) {
+	result := &TransactionPages{}
 	query := newTransactionQuery(querys...)
-	ep := endpoint.GetEndpoint(live, endpoint.Transaction.Transactions)
-	url, _ := urlAddQuery(fmt.Sprintf(ep, accountID), query)
-	tc.endpoint = url
-	tc.method = http.MethodGet
-	data, err := tc.connect()
-	if err != nil {
+	ep := tc.getEndpoint(endpoint.Transaction.Transactions)
+	url, _ := urlAddQuery(fmt.Sprintf(ep, tc.client.accountID), query)
+	if err := tc.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
 		return nil, err
 	}
-	if err = json.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal %s to %T, %v", string(data), result, err)
-	}
 	return result, nil
 }
 
 // GetTransactionById is to get the details of a single Account Transaction.
-func (tc *transaction) GetTransactionById(live bool, accountID, transactionID string) (string, error) {
-	ep := endpoint.GetEndpoint(live, endpoint.Transaction.TransactionById)
-	tc.endpoint = fmt.Sprintf(ep, accountID, transactionID)
-	tc.method = http.MethodGet
-	data, err := tc.connect()
-	if err != nil {
-		return "", err
+func (tc *TransactionService) GetTransactionById(transactionID string) (*TransactionDetails, error) {
+	return tc.GetTransactionByIdContext(context.Background(), transactionID)
+}
+
+// GetTransactionByIdContext is GetTransactionById with a context to cancel the request
+// or to set its deadline.
+func (tc *TransactionService) GetTransactionByIdContext(ctx context.Context, transactionID string) (*TransactionDetails, error) {
+	result := &TransactionDetails{}
+	ep := tc.getEndpoint(endpoint.Transaction.TransactionById)
+	url := fmt.Sprintf(ep, tc.client.accountID, transactionID)
+	if err := tc.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	return string(data), nil
+	return result, nil
 }
 
 // GetTransactionRangeById is to get a range of Transactions
 // for an Account based on the Transaction IDs.
-func (tc *transaction) GetTransactionRangeById(live bool, accountID, fromID, toID string, opts ...transactionOpts) (string, error) {
+func (tc *TransactionService) GetTransactionRangeById(fromID, toID string, opts ...TransactionOpts) (*TransactionList, error) {
+	return tc.GetTransactionRangeByIdContext(context.Background(), fromID, toID, opts...)
+}
+
+// GetTransactionRangeByIdContext is GetTransactionRangeById with a context to cancel the request
+// or to set its deadline.
+func (tc *TransactionService) GetTransactionRangeByIdContext(ctx context.Context, fromID, toID string, opts ...TransactionOpts) (*TransactionList, error) {
 	query := newTransactionQuery(opts...)
 	queryMap := struct {
 		From string `json:"from"`
 		To   string `json:"to"`
 		Type string `json:"type,omitempty"`
 	}{fromID, toID, query.Type}
-	ep := endpoint.GetEndpoint(live, endpoint.Transaction.TransactionIdRange)
-	url, err := urlAddQuery(fmt.Sprintf(ep, accountID), queryMap)
+	ep := tc.getEndpoint(endpoint.Transaction.TransactionIdRange)
+	url, err := urlAddQuery(fmt.Sprintf(ep, tc.client.accountID), queryMap)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	tc.endpoint = url
-	tc.method = http.MethodGet
-	data, err := tc.connect()
-	if err != nil {
-		return "", err
+	result := &TransactionList{}
+	if err = tc.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	return string(data), err
+	return result, nil
 }
 
 // GetTransactionRange is to get a range of Transactions for
 // an Account starting at (but not including) a provided Transaction ID.
-func (tc *transaction) GetTransactionRange(live bool, accountID, transactionID string, opts ...transactionOpts) (string, error) {
-	query := newTransactionQuery(opts...)
-	ep := endpoint.GetEndpoint(live, endpoint.Transaction.TransactionIdRange)
-	url := fmt.Sprintf(ep, accountID) + "?id=" + transactionID
-	url, err := urlAddQuery(url, &transactionQuery{Type: query.Type})
+func (tc *TransactionService) GetTransactionRange(transactionID string, opts ...TransactionOpts) (*TransactionList, error) {
+	return tc.GetTransactionRangeContext(context.Background(), transactionID, opts...)
+}
+
+// GetTransactionRangeContext is GetTransactionRange with a context to cancel the request
+// or to set its deadline.
+func (tc *TransactionService) GetTransactionRangeContext(ctx context.Context, transactionID string, opts ...TransactionOpts) (*TransactionList, error) {
+	url, err := tc.transactionSinceURL(transactionID, opts...)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	tc.endpoint = url
-	tc.method = http.MethodGet
-	data, err := tc.connect()
-	if err != nil {
-		return "", nil
+	result := &TransactionList{}
+	if err = tc.connectJSON(ctx, &request{method: http.MethodGet, endpoint: url}, result); err != nil {
+		return nil, err
 	}
-	return string(data), nil
+	return result, nil
 }
 
-//
-// GET	/v3/accounts/{accountID}/transactions/stream
-// Get a stream of Transactions for an Account starting from when the request is made.
+// transactionSinceURL is the url of the transactions after transactionID.
+func (tc *TransactionService) transactionSinceURL(transactionID string, opts ...TransactionOpts) (string, error) {
+	query := newTransactionQuery(opts...)
+	queryMap := struct {
+		ID   string `json:"id"`
+		Type string `json:"type,omitempty"`
+	}{transactionID, query.Type}
+	ep := tc.getEndpoint(endpoint.Transaction.TransactionSinceId)
+	return urlAddQuery(fmt.Sprintf(ep, tc.client.accountID), queryMap)
+}
+
+// StreamTransactions is to get a stream of Transactions for an Account starting
+// from when the request is made. The stream stays open until ctx is done,
+// every transaction and HEARTBEAT message is sent as an event. When the stream
+// is dropped it is reconnected and the transactions missed in the meantime are
+// sent before a RECONNECT event. Errors are sent on the error channel which
+// must be received along with the events, both channels are closed when the
+// stream ends.
+func (tc *TransactionService) StreamTransactions(ctx context.Context) (<-chan TransactionEvent, <-chan error) { // {{{
+	events := make(chan TransactionEvent)
+	errs := make(chan error)
+	ep := tc.getEndpoint(endpoint.Transaction.TransactionStream)
+	url := fmt.Sprintf(ep, tc.client.accountID)
+	// lastID is the last transaction sent or reported by a heartbeat,
+	// every transaction up to it has been sent or precedes the stream.
+	var lastID int64
+	send := func(event TransactionEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+	handle := func(event TransactionEvent) {
+		if event.Heartbeat != nil {
+			if id, err := strconv.ParseInt(event.Heartbeat.LastTransactionID, 10, 64); err == nil && id > lastID {
+				lastID = id
+			}
+			send(event)
+			return
+		}
+		id, err := strconv.ParseInt(event.Transaction.Base().ID, 10, 64)
+		if err == nil {
+			if id <= lastID {
+				return
+			}
+			lastID = id
+		}
+		send(event)
+	}
+	go func() {
+		defer close(errs)
+		defer close(events)
+		err := tc.runStream(ctx, &request{method: http.MethodGet, endpoint: url}, streamHandler{
+			message: func(line []byte) {
+				event, err := decodeTransactionEvent(line)
+				if err != nil {
+					sendError(ctx, errs, err)
+					return
+				}
+				handle(event)
+			},
+			reconnected: func(dropped error) error {
+				if lastID > 0 {
+					missed, err := tc.transactionsSinceID(ctx, strconv.FormatInt(lastID, 10))
+					if err != nil {
+						return fmt.Errorf("failed to get transactions missed by the stream, %w", err)
+					}
+					for _, line := range missed {
+						event, err := decodeTransactionEvent(line)
+						if err != nil {
+							return err
+						}
+						handle(event)
+					}
+				}
+				send(TransactionEvent{Type: StreamReconnect, Err: dropped})
+				return nil
+			},
+		})
+		if err != nil {
+			sendError(ctx, errs, err)
+		}
+	}()
+	return events, errs
+} // }}}
+
+// transactionsSinceID is to get the transactions after transactionID, the
+// pages are requested until the last transaction of the account as a page
+// of sinceid is limited.
+func (tc *TransactionService) transactionsSinceID(ctx context.Context, transactionID string) ([]json.RawMessage, error) { // {{{
+	var transactions []json.RawMessage
+	for {
+		url, err := tc.transactionSinceURL(transactionID)
+		if err != nil {
+			return nil, err
+		}
+		data, err := tc.connect(ctx, &request{method: http.MethodGet, endpoint: url})
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Transactions      []json.RawMessage `json:"transactions"`
+			LastTransactionID string            `json:"lastTransactionID"`
+		}
+		if err = json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transactions since %v, %v", transactionID, err)
+		}
+		transactions = append(transactions, result.Transactions...)
+		if len(result.Transactions) == 0 {
+			return transactions, nil
+		}
+		var last struct {
+			ID string `json:"id"`
+		}
+		if err = json.Unmarshal(result.Transactions[len(result.Transactions)-1], &last); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transactions since %v, %v", transactionID, err)
+		}
+		pageID, _ := strconv.ParseInt(last.ID, 10, 64)
+		sinceID, _ := strconv.ParseInt(transactionID, 10, 64)
+		accountLastID, _ := strconv.ParseInt(result.LastTransactionID, 10, 64)
+		// a page not going further than the previous one is the last one.
+		if pageID >= accountLastID || pageID <= sinceID {
+			return transactions, nil
+		}
+		transactionID = last.ID
+	}
+} // }}}
 
 type transactionQuery struct {
 	FromDate string `json:"from,omitempty"`
@@ -118,9 +848,10 @@ type transactionQuery struct {
 
 type transactionFunc struct{}
 
-type transactionOpts func(*transactionQuery)
+// TransactionOpts is an option of the transaction queries.
+type TransactionOpts func(*transactionQuery)
 
-func newTransactionQuery(opts ...transactionOpts) *transactionQuery {
+func newTransactionQuery(opts ...TransactionOpts) *transactionQuery {
 	t := &transactionQuery{}
 	for _, opt := range opts {
 		opt(t)
@@ -129,7 +860,7 @@ func newTransactionQuery(opts ...transactionOpts) *transactionQuery {
 }
 
 // WithType is a filter for restricting the types of Transactions to retrieve.
-func (tf *transactionFunc) WithType(transactionType ...string) transactionOpts {
+func (tf *transactionFunc) WithType(transactionType ...string) TransactionOpts {
 	return func(tq *transactionQuery) {
 		tq.Type = strings.Join(transactionType, ",")
 	}
@@ -137,19 +868,19 @@ func (tf *transactionFunc) WithType(transactionType ...string) transactionOpts {
 
 // WithFromDate is the starting time (inclusive) of the time range for the
 // Transactions being queried. [default=Account Creation Time]
-func (tf *transactionFunc) WithFromDate(from time.Time) transactionOpts {
+func (tf *transactionFunc) WithFromDate(from time.Time) TransactionOpts {
 	return func(tq *transactionQuery) { tq.FromDate = from.Format(time.RFC3339) }
 }
 
 // WithToDate is the ending time (inclusive) of the time range for the
 // Transactions being queried. [default=Request Time]
-func (tf *transactionFunc) WithToDate(to time.Time) transactionOpts {
+func (tf *transactionFunc) WithToDate(to time.Time) TransactionOpts {
 	return func(tq *transactionQuery) { tq.ToDate = to.Format(time.RFC3339) }
 }
 
 // WithPageSize is the number of Transactions to include in each page
 // of the results. [default=100, maximum=1000]
-func (tf *transactionFunc) WithPageSize(size int) transactionOpts {
+func (tf *transactionFunc) WithPageSize(size int) TransactionOpts {
 	return func(tq *transactionQuery) {
 		if size < 1 || size > 1000 {
 			tq.PageSize = 100
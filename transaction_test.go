@@ -0,0 +1,245 @@
+package gooanda_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+)
+
+const (
+	testOrderFill            = `{"id":"7","time":"2021-01-01T00:00:01.000000000Z","userID":1,"accountID":"101-001-1-001","batchID":"6","requestID":"42","type":"ORDER_FILL","orderID":"6","instrument":"EUR_USD","units":"100","price":"1.22010","reason":"MARKET_ORDER"}`
+	testTransactionHeartbeat = `{"type":"HEARTBEAT","lastTransactionID":"7","time":"2021-01-01T00:00:05.000000000Z"}`
+)
+
+func TestStreamTransactions(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/transactions/stream" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		for _, msg := range []string{testOrderFill, testTransactionHeartbeat} {
+			fmt.Fprintln(w, msg)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.Transactions().StreamTransactions(ctx)
+	for _, want := range []string{"ORDER_FILL", "HEARTBEAT"} {
+		select {
+		case event := <-events:
+			if event.Type != want {
+				t.Errorf("event type = %v, want %v", event.Type, want)
+			}
+			if fill, ok := event.Transaction.(*gooanda.OrderFillTransaction); want == "ORDER_FILL" && (!ok || fill.ID != "7" || fill.OrderID != "6") {
+				t.Errorf("transaction = %+v", event.Transaction)
+			}
+			if want == "HEARTBEAT" && (event.Heartbeat == nil || event.Heartbeat.LastTransactionID != "7") {
+				t.Errorf("heartbeat = %+v", event.Heartbeat)
+			}
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for events")
+		}
+	}
+	cancel()
+	for range events {
+	}
+}
+
+func TestGetTransactionById(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/transactions/7" {
+			t.Errorf("path = %v", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"transaction":%v,"lastTransactionID":"7"}`, testOrderFill)
+	})
+	details, err := client.Transactions().GetTransactionById("7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill, ok := details.Transaction.(*gooanda.OrderFillTransaction)
+	if !ok {
+		t.Fatalf("transaction = %T, want *gooanda.OrderFillTransaction", details.Transaction)
+	}
+	if fill.ID != "7" || fill.OrderID != "6" || !fill.Units.Equal(dec("100")) || !fill.Price.Equal(dec("1.2201")) {
+		t.Errorf("transaction = %+v", fill)
+	}
+}
+
+func TestGetTransactionRange(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/accounts/101-001-1-001/transactions/sinceid" || r.URL.Query().Get("id") != "5" {
+			t.Errorf("url = %v", r.URL)
+		}
+		fmt.Fprintf(w, `{"transactions":[{"id":"6","type":"MARKET_ORDER","instrument":"EUR_USD","units":"100"},%v,{"id":"8","type":"NEW_TYPE"}],"lastTransactionID":"8"}`, testOrderFill)
+	})
+	list, err := client.Transactions().GetTransactionRange("5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, tr := range list.Transactions {
+		types = append(types, fmt.Sprintf("%T", tr))
+	}
+	if fmt.Sprint(types) != "[*gooanda.MarketOrderTransaction *gooanda.OrderFillTransaction *gooanda.BaseTransaction]" {
+		t.Errorf("types = %v", types)
+	}
+	if list.LastTransactionID != "8" {
+		t.Errorf("lastTransactionID = %v", list.LastTransactionID)
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// testTransactionTypes is the concrete transaction of every fixture
+// of testdata/transactions.
+var testTransactionTypes = map[string]string{ // {{{
+	"CREATE":                                "*gooanda.CreateTransaction",
+	"CLOSE":                                 "*gooanda.CloseTransaction",
+	"REOPEN":                                "*gooanda.ReopenTransaction",
+	"CLIENT_CONFIGURE":                      "*gooanda.ClientConfigureTransaction",
+	"CLIENT_CONFIGURE_REJECT":               "*gooanda.ClientConfigureRejectTransaction",
+	"TRANSFER_FUNDS":                        "*gooanda.TransferFundsTransaction",
+	"TRANSFER_FUNDS_REJECT":                 "*gooanda.TransferFundsRejectTransaction",
+	"MARKET_ORDER":                          "*gooanda.MarketOrderTransaction",
+	"MARKET_ORDER_REJECT":                   "*gooanda.MarketOrderRejectTransaction",
+	"FIXED_PRICE_ORDER":                     "*gooanda.FixedPriceOrderTransaction",
+	"LIMIT_ORDER":                           "*gooanda.LimitOrderTransaction",
+	"LIMIT_ORDER_REJECT":                    "*gooanda.LimitOrderRejectTransaction",
+	"STOP_ORDER":                            "*gooanda.StopOrderTransaction",
+	"STOP_ORDER_REJECT":                     "*gooanda.StopOrderRejectTransaction",
+	"MARKET_IF_TOUCHED_ORDER":               "*gooanda.MarketIfTouchedOrderTransaction",
+	"MARKET_IF_TOUCHED_ORDER_REJECT":        "*gooanda.MarketIfTouchedOrderRejectTransaction",
+	"TAKE_PROFIT_ORDER":                     "*gooanda.TakeProfitOrderTransaction",
+	"TAKE_PROFIT_ORDER_REJECT":              "*gooanda.TakeProfitOrderRejectTransaction",
+	"STOP_LOSS_ORDER":                       "*gooanda.StopLossOrderTransaction",
+	"STOP_LOSS_ORDER_REJECT":                "*gooanda.StopLossOrderRejectTransaction",
+	"GUARANTEED_STOP_LOSS_ORDER":            "*gooanda.GuaranteedStopLossOrderTransaction",
+	"GUARANTEED_STOP_LOSS_ORDER_REJECT":     "*gooanda.GuaranteedStopLossOrderRejectTransaction",
+	"TRAILING_STOP_LOSS_ORDER":              "*gooanda.TrailingStopLossOrderTransaction",
+	"TRAILING_STOP_LOSS_ORDER_REJECT":       "*gooanda.TrailingStopLossOrderRejectTransaction",
+	"ONE_CANCELS_ALL_ORDER":                 "*gooanda.BaseTransaction",
+	"ONE_CANCELS_ALL_ORDER_REJECT":          "*gooanda.BaseTransaction",
+	"ONE_CANCELS_ALL_ORDER_TRIGGERED":       "*gooanda.BaseTransaction",
+	"ORDER_FILL":                            "*gooanda.OrderFillTransaction",
+	"ORDER_CANCEL":                          "*gooanda.OrderCancelTransaction",
+	"ORDER_CANCEL_REJECT":                   "*gooanda.OrderCancelRejectTransaction",
+	"ORDER_CLIENT_EXTENSIONS_MODIFY":        "*gooanda.OrderClientExtensionsModifyTransaction",
+	"ORDER_CLIENT_EXTENSIONS_MODIFY_REJECT": "*gooanda.OrderClientExtensionsModifyRejectTransaction",
+	"TRADE_CLIENT_EXTENSIONS_MODIFY":        "*gooanda.TradeClientExtensionsModifyTransaction",
+	"TRADE_CLIENT_EXTENSIONS_MODIFY_REJECT": "*gooanda.TradeClientExtensionsModifyRejectTransaction",
+	"MARGIN_CALL_ENTER":                     "*gooanda.MarginCallEnterTransaction",
+	"MARGIN_CALL_EXTEND":                    "*gooanda.MarginCallExtendTransaction",
+	"MARGIN_CALL_EXIT":                      "*gooanda.MarginCallExitTransaction",
+	"DELAYED_TRADE_CLOSURE":                 "*gooanda.DelayedTradeClosureTransaction",
+	"DAILY_FINANCING":                       "*gooanda.DailyFinancingTransaction",
+	"DIVIDEND_ADJUSTMENT":                   "*gooanda.DividendAdjustmentTransaction",
+	"RESET_RESETTABLE_PL":                   "*gooanda.ResetResettablePLTransaction",
+} // }}}
+
+// TestTransactionTypes is to decode every fixture of testdata/transactions
+// and to compare it encoded again with its golden file, run with -update
+// to write the golden files.
+func TestTransactionTypes(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "transactions", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != len(testTransactionTypes) {
+		t.Errorf("%d fixtures for %d transaction types", len(fixtures), len(testTransactionTypes))
+	}
+	for _, fixture := range fixtures {
+		transactionType := strings.TrimSuffix(filepath.Base(fixture), ".json")
+		t.Run(transactionType, func(t *testing.T) {
+			data, err := ioutil.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"transaction":%s,"lastTransactionID":"41"}`, data)
+			})
+			details, err := client.Transactions().GetTransactionById("1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := fmt.Sprintf("%T", details.Transaction), testTransactionTypes[transactionType]; got != want {
+				t.Errorf("transaction = %v, want %v", got, want)
+			}
+			if got := details.Transaction.Base().Type; got != transactionType {
+				t.Errorf("type = %v, want %v", got, transactionType)
+			}
+			got, err := json.MarshalIndent(details.Transaction, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+			for _, key := range missingKeys(t, data, got) {
+				t.Errorf("field %v of the fixture is not decoded", key)
+			}
+			golden := strings.TrimSuffix(fixture, ".json") + ".golden"
+			if *update {
+				if err = ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// missingKeys is to get the keys of the json fixture which are not in got,
+// but for the zero decimals.
+func missingKeys(t *testing.T, fixture, got []byte) []string {
+	var f, g interface{}
+	if err := json.Unmarshal(fixture, &f); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatal(err)
+	}
+	var missing []string
+	var walk func(path string, f, g interface{})
+	walk = func(path string, f, g interface{}) {
+		switch f := f.(type) {
+		case map[string]interface{}:
+			g, _ := g.(map[string]interface{})
+			for k, v := range f {
+				if _, ok := g[k]; !ok {
+					missing = append(missing, path+k)
+					continue
+				}
+				walk(path+k+".", v, g[k])
+			}
+		case []interface{}:
+			g, _ := g.([]interface{})
+			for i, v := range f {
+				if i < len(g) {
+					walk(fmt.Sprintf("%v%d.", path, i), v, g[i])
+				}
+			}
+		}
+	}
+	walk("", f, g)
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,143 @@
+package gooanda
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	// DefaultRESTTimeout is the time limit of a REST request.
+	DefaultRESTTimeout = 5 * time.Second
+	// DefaultMaxIdleConns is the number of idle connections kept for reuse.
+	DefaultMaxIdleConns = 100
+)
+
+// newTransport is to create the transport shared by the REST and stream
+// requests of a client, keep-alive connections are reused across requests.
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = DefaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConns
+	return transport
+}
+
+// WithHTTPClient is the http client used to send every request, the timeout
+// of the http client is used for REST requests. The transport options are
+// applied to a copy of its transport when it is an *http.Transport, or nil
+// for http.DefaultTransport, and are ignored for any other RoundTripper.
+func WithHTTPClient(httpClient *http.Client) ClientOpts {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithTimeout is the time limit of every REST request. [default=5s]
+func WithTimeout(timeout time.Duration) ClientOpts {
+	return func(c *Client) { c.restTimeout = timeout }
+}
+
+// WithStreamTimeout is the time limit to connect a stream, i.e. until the
+// response headers are received, zero for no limit. Once connected a stream
+// stays open until its context is done. [default=0]
+func WithStreamTimeout(timeout time.Duration) ClientOpts {
+	return func(c *Client) { c.streamTimeout = timeout }
+}
+
+// WithMaxIdleConns is the number of idle keep-alive connections kept for reuse.
+// [default=100]
+func WithMaxIdleConns(n int) ClientOpts {
+	return withTransport(func(t *http.Transport) {
+		t.MaxIdleConns = n
+		t.MaxIdleConnsPerHost = n
+	})
+}
+
+// WithProxy is the proxy used to send every request. [default=proxy from environment]
+func WithProxy(proxyURL *url.URL) ClientOpts {
+	return withTransport(func(t *http.Transport) { t.Proxy = http.ProxyURL(proxyURL) })
+}
+
+// WithTLSConfig is the tls configuration used to connect to the api.
+func WithTLSConfig(config *tls.Config) ClientOpts {
+	return withTransport(func(t *http.Transport) { t.TLSClientConfig = config })
+}
+
+// withTransport is to set an option of the transport once the http client
+// is known, whatever the order of the options.
+func withTransport(opt func(*http.Transport)) ClientOpts {
+	return func(c *Client) { c.transportOpts = append(c.transportOpts, opt) }
+}
+
+// initHTTPClients is to create the REST and stream http clients
+// sharing the same transport.
+func (c *Client) initHTTPClients() { // {{{
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Transport: c.applyTransportOpts(newTransport()), Timeout: c.restTimeout}
+	} else if len(c.transportOpts) > 0 {
+		// the transport given may be shared, the options are applied
+		// to a copy of it.
+		transport, ok := c.httpClient.Transport.(*http.Transport)
+		if c.httpClient.Transport == nil {
+			transport, ok = http.DefaultTransport.(*http.Transport)
+		}
+		if ok {
+			httpClient := *c.httpClient
+			httpClient.Transport = c.applyTransportOpts(transport.Clone())
+			c.httpClient = &httpClient
+		}
+	}
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	c.streamClient = &streamClient
+} // }}}
+
+// applyTransportOpts is to apply the transport options to transport.
+func (c *Client) applyTransportOpts(transport *http.Transport) *http.Transport {
+	for _, opt := range c.transportOpts {
+		opt(transport)
+	}
+	return transport
+}
+
+// doStream is to send the stream request, the stream timeout applies
+// until the response headers are received.
+func (c *Client) doStream(req *http.Request) (*http.Response, error) {
+	if c.streamTimeout <= 0 {
+		return c.streamClient.Do(req)
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(c.streamTimeout, cancel)
+	resp, err := c.streamClient.Do(req.WithContext(ctx))
+	if !timer.Stop() {
+		cancel()
+		if err == nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("failed to connect stream within %v", c.streamTimeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody is to release the context of a stream when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,133 @@
+package gooanda_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kokweikhong/gooanda"
+	"github.com/kokweikhong/gooanda/endpoint"
+)
+
+func TestRESTTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}, gooanda.WithTimeout(50*time.Millisecond), gooanda.WithoutRetry())
+	start := time.Now()
+	if _, err := client.Accounts().GetAccountList(); err == nil {
+		t.Fatal("err = nil, want timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("request took %v, want about 50ms", elapsed)
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}, gooanda.WithStreamTimeout(50*time.Millisecond), gooanda.WithoutRetry(),
+		gooanda.WithoutStreamReconnect())
+	events, errs := client.Pricing().StreamPrices(context.Background(), []string{"EUR_USD"})
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("err = nil, want stream timeout")
+		}
+	case <-events:
+		t.Error("event received from stalled stream")
+	case <-time.After(900 * time.Millisecond):
+		t.Fatal("stream timeout not applied")
+	}
+}
+
+func TestStreamOutlivesRESTTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			fmt.Fprintln(w, testHeartbeat)
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+		<-r.Context().Done()
+	}, gooanda.WithTimeout(20*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := client.Pricing().StreamPrices(ctx, []string{"EUR_USD"})
+	for i := 0; i < 3; i++ {
+		select {
+		case <-events:
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for heartbeats")
+		}
+	}
+}
+
+func TestTransportOptionsWithHTTPClient(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		fmt.Fprint(w, `{"accounts":[]}`)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	transport := &http.Transport{}
+	env := endpoint.Custom("http://api.invalid", "http://stream.invalid")
+	client := gooanda.NewClient("token", gooanda.WithEnvironment(env),
+		gooanda.WithProxy(proxyURL), gooanda.WithHTTPClient(&http.Client{Transport: transport}))
+	if _, err := client.Accounts().GetAccountList(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&proxied); n != 1 {
+		t.Errorf("proxied = %d, want the request sent through the proxy", n)
+	}
+	if transport.Proxy != nil {
+		t.Error("transport of the http client modified, want a copy")
+	}
+}
+
+func BenchmarkGetPricingInformation(b *testing.B) {
+	env := newTestEnvironment(b, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"time":"2021-01-01T00:00:00.000000000Z","prices":[%v]}`, testPrice)
+	})
+	instruments := []string{"EUR_USD"}
+
+	b.Run("SharedTransport", func(b *testing.B) {
+		client := gooanda.NewClient(b.Name(), gooanda.WithEnvironment(env),
+			gooanda.WithRateLimit(gooanda.RateLimit{}))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.Pricing().GetPricingInformation(instruments); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// NewClientPerCall is the previous behaviour of a new http client and
+	// connection for every request.
+	b.Run("NewClientPerCall", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			httpClient := &http.Client{
+				Transport: &http.Transport{DisableKeepAlives: true},
+				Timeout:   5 * time.Second,
+			}
+			client := gooanda.NewClient(b.Name(), gooanda.WithEnvironment(env),
+				gooanda.WithRateLimit(gooanda.RateLimit{}), gooanda.WithHTTPClient(httpClient))
+			if _, err := client.Pricing().GetPricingInformation(instruments); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}